@@ -0,0 +1,28 @@
+package ers
+
+// Annotate は, err の trace の Values に key と value を追記した新しいエラーを返す.
+// err が *Error でない場合は NewWrap でラップしてから追記する.
+// 元のエラーや trace は変更しない.
+// err が nil の場合は nil を返す.
+func Annotate(err error, key string, value any) error {
+	if err == nil {
+		return nil
+	}
+
+	e, ok := err.(*Error)
+	if !ok {
+		e = NewWrap(err, WithFrame(1)).(*Error)
+	}
+	v := *e
+	if v.trace == nil {
+		v.trace = newTrace("")
+	} else {
+		c := *v.trace
+		v.trace = &c
+	}
+	// 元の Values と配列を共有しないよう, 新しいスライスに追記する
+	values := make([]any, 0, len(v.trace.Values)+2)
+	values = append(values, v.trace.Values...)
+	v.trace.Values = append(values, key, value)
+	return &v
+}
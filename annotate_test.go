@@ -0,0 +1,82 @@
+package ers
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAnnotate1(t *testing.T) {
+	src := ErrNotFound.WithTrace("text").(*Error)
+	err := Annotate(Annotate(src, "user_id", 1), "request_id", "abc").(*Error)
+
+	want := []any{"user_id", 1, "request_id", "abc"}
+	if got := err.trace.Values; !reflect.DeepEqual(got, want) {
+		t.Errorf("\n  got: %v\n  want: %v", got, want)
+		return
+	}
+	if got := err.trace.Text; got != "text" {
+		t.Errorf("\n  got: %s\n  want: %s", got, "text")
+		return
+	}
+	if !Is(err, ErrNotFound) {
+		t.Errorf("Expected to match %v", ErrNotFound)
+		return
+	}
+
+	// 元のエラーは変更しない
+	if len(src.trace.Values) != 0 {
+		t.Errorf("\n  got: %v\n  want: empty", src.trace.Values)
+		return
+	}
+	if ErrNotFound.trace != nil {
+		t.Errorf("\n  got: %v\n  want: <nil>", ErrNotFound.trace)
+		return
+	}
+}
+
+func TestAnnotate2(t *testing.T) {
+	src := errors.New("error")
+	err, ok := Annotate(src, "key", "value").(*Error)
+	if !ok {
+		t.Errorf("\n  got: %T\n  want: *Error", err)
+		return
+	}
+	if !errors.Is(err, src) {
+		t.Errorf("Expected to match %v", src)
+		return
+	}
+	if got, want := err.trace.Values, []any{"key", "value"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("\n  got: %v\n  want: %v", got, want)
+		return
+	}
+
+	if err := Annotate(nil, "key", "value"); err != nil {
+		t.Errorf("\n  got: %v\n  want: <nil>", err)
+		return
+	}
+}
+
+func TestAnnotate3(t *testing.T) {
+	// 容量に余裕のある Values を持つ trace でも, 元の配列を書き換えない
+	trace := NewTrace("text")
+	trace.Values = make([]any, 1, 8)
+	trace.Values[0] = "base"
+	src := ErrNotFound.WithTrace(trace).(*Error)
+
+	a := Annotate(src, "a", 1).(*Error)
+	b := Annotate(src, "b", 2).(*Error)
+
+	if got, want := a.trace.Values, []any{"base", "a", 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("\n  got: %v\n  want: %v", got, want)
+		return
+	}
+	if got, want := b.trace.Values, []any{"base", "b", 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("\n  got: %v\n  want: %v", got, want)
+		return
+	}
+	if got, want := src.trace.Values, []any{"base"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("\n  got: %v\n  want: %v", got, want)
+		return
+	}
+}
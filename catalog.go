@@ -0,0 +1,84 @@
+package ers
+
+import (
+	"fmt"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Catalog は, domain を共有するアプリ固有のエラーを reason ごとに管理する.
+type Catalog struct {
+	mu     sync.RWMutex
+	domain string
+	// reason に付与するプレフィックス
+	prefix string
+	errs   map[string]*Error
+}
+
+type CatalogOption func(c *Catalog)
+
+// WithReasonPrefix は, 定義するエラーの reason に prefix を付与する.
+// 複数のサービスで reason が衝突しないよう "billing/" のような名前空間を付与する用途を想定している.
+// 定義したエラーの Reason や Is による比較は, プレフィックスを含む完全な reason で行う.
+func WithReasonPrefix(prefix string) CatalogOption {
+	return func(c *Catalog) {
+		c.prefix = prefix
+	}
+}
+
+func NewCatalog(domain string, options ...CatalogOption) *Catalog {
+	c := &Catalog{
+		domain: domain,
+		errs:   map[string]*Error{},
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// Define は, domain を付与したエラーを定義して登録する.
+// 登録済みの reason を定義した場合は panic する.
+// パッケージ変数の初期化で使うことを想定している.
+func (c *Catalog) Define(code codes.Code, reason string, message string) *Error {
+	e, err := c.define(code, reason, message)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// TryDefine は, Define と同じくエラーを定義して登録する.
+// 登録済みの reason を定義した場合は panic せずに ErrAlreadyExists を返す.
+func (c *Catalog) TryDefine(code codes.Code, reason string, message string) (*Error, error) {
+	return c.define(code, reason, message)
+}
+
+// Lookup は, reason から登録済みのエラーを返す.
+// reason はプレフィックスを含む完全な reason と, Define で指定した reason のどちらでも良い.
+func (c *Catalog) Lookup(reason string) (*Error, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if e, ok := c.errs[reason]; ok {
+		return e, ok
+	}
+	e, ok := c.errs[c.prefix+reason]
+	return e, ok
+}
+
+func (c *Catalog) define(code codes.Code, reason string, message string) (*Error, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	reason = c.prefix + reason
+	if _, ok := c.errs[reason]; ok {
+		return nil, ErrAlreadyExists.WithTrace(fmt.Sprintf("reason %q is already defined in %q", reason, c.domain))
+	}
+
+	// Define や TryDefine の呼び出し元をフレームとして記録する
+	e := newError(code, reason, message, wrapOptions{Frame: 1, Domain: c.domain, sentinel: true})
+	c.errs[reason] = e
+	return e, nil
+}
@@ -0,0 +1,97 @@
+package ers
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestCatalog1(t *testing.T) {
+	catalog := NewCatalog("example.com")
+	errUserNotFound := catalog.Define(codes.NotFound, "UserNotFound", "ユーザーが存在しません。")
+
+	if errUserNotFound.Domain() != "example.com" {
+		t.Errorf("\n  got: %s\n  want: %s", errUserNotFound.Domain(), "example.com")
+		return
+	}
+	if errUserNotFound.Code() != codes.NotFound || errUserNotFound.Reason() != "UserNotFound" {
+		t.Errorf("\n  got: %s, %s\n  want: %s, %s", errUserNotFound.Code(), errUserNotFound.Reason(), codes.NotFound, "UserNotFound")
+		return
+	}
+
+	got, ok := catalog.Lookup("UserNotFound")
+	if !ok || got != errUserNotFound {
+		t.Errorf("\n  got: %v, %t\n  want: %v, true", got, ok, errUserNotFound)
+		return
+	}
+	if _, ok := catalog.Lookup("Unknown"); ok {
+		t.Errorf("Expected Lookup to fail")
+		return
+	}
+
+	if !Is(W(errUserNotFound.WithTrace("user_id: 1")), got) {
+		t.Errorf("Expected to match %v", got)
+		return
+	}
+}
+
+func TestCatalog2(t *testing.T) {
+	catalog := NewCatalog("example.com")
+	catalog.Define(codes.NotFound, "UserNotFound", "")
+
+	// TryDefine は重複をエラーとして返す
+	if _, err := catalog.TryDefine(codes.NotFound, "UserNotFound", ""); !Is(err, ErrAlreadyExists) {
+		t.Errorf("\n  got: %v\n  want: %v", err, ErrAlreadyExists)
+		return
+	}
+
+	// Define は重複を panic で通知する
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected panic")
+		}
+	}()
+	catalog.Define(codes.Internal, "UserNotFound", "")
+}
+
+func TestCatalogReasonPrefix1(t *testing.T) {
+	billing := NewCatalog("billing.example.com", WithReasonPrefix("billing/"))
+	account := NewCatalog("account.example.com", WithReasonPrefix("account/"))
+	errBilling := billing.Define(codes.NotFound, "NotFound", "")
+	errAccount := account.Define(codes.NotFound, "NotFound", "")
+
+	if got := W(errBilling).(*Error).Reason(); got != "billing/NotFound" {
+		t.Errorf("\n  got: %s\n  want: %s", got, "billing/NotFound")
+		return
+	}
+	if got := errorInfoOf(t, errBilling.GRPCStatus()).GetReason(); got != "billing/NotFound" {
+		t.Errorf("\n  got: %s\n  want: %s", got, "billing/NotFound")
+		return
+	}
+
+	// プレフィックスを含む完全な reason で比較する
+	tests := []struct {
+		err    error
+		target error
+		want   bool
+	}{
+		{err: W(errBilling), target: errBilling, want: true},
+		{err: W(errBilling), target: errAccount, want: false},
+		{err: W(errBilling), target: ErrNotFound, want: false},
+		{err: W(errBilling), target: New(codes.NotFound, "billing/NotFound", ""), want: true},
+	}
+	for _, test := range tests {
+		if got := Is(test.err, test.target); got != test.want {
+			t.Errorf("[%v]\n  got: %t\n  want: %t", test.target, got, test.want)
+			return
+		}
+	}
+
+	// Lookup は完全な reason と定義時の reason のどちらでも引ける
+	for _, reason := range []string{"NotFound", "billing/NotFound"} {
+		if got, ok := billing.Lookup(reason); !ok || got != errBilling {
+			t.Errorf("[%s]\n  got: %v, %t\n  want: %v, true", reason, got, ok, errBilling)
+			return
+		}
+	}
+}
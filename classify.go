@@ -0,0 +1,61 @@
+package ers
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Classifiers は, Classify で標準ライブラリなどのエラーから codes.Code を推定するルール.
+// 先頭から順に評価し, 最初にマッチしたルールのコードを採用する.
+// 利用者が差し替えや追加を行える.
+var Classifiers = []func(error) (codes.Code, bool){
+	contextCode,
+	classifyFS,
+	classifyNetTimeout,
+	classifyEOF,
+}
+
+// Classify は, Classifiers に従って err に適切な codes.Code を推定する.
+// いずれのルールにもマッチしない場合は, ラップ先を辿って解決したコードを返す.
+func Classify(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+	for _, classifier := range Classifiers {
+		if code, ok := classifier(err); ok {
+			return code
+		}
+	}
+	return codeOf(err)
+}
+
+func classifyFS(err error) (codes.Code, bool) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return codes.NotFound, true
+	case errors.Is(err, fs.ErrExist):
+		return codes.AlreadyExists, true
+	case errors.Is(err, fs.ErrPermission):
+		return codes.PermissionDenied, true
+	}
+	return codes.Unknown, false
+}
+
+func classifyNetTimeout(err error) (codes.Code, bool) {
+	var v net.Error
+	if errors.As(err, &v) && v.Timeout() {
+		return codes.DeadlineExceeded, true
+	}
+	return codes.Unknown, false
+}
+
+func classifyEOF(err error) (codes.Code, bool) {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return codes.Unavailable, true
+	}
+	return codes.Unknown, false
+}
@@ -0,0 +1,72 @@
+package ers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+type testTimeoutError struct{}
+
+func (e *testTimeoutError) Error() string   { return "timeout" }
+func (e *testTimeoutError) Timeout() bool   { return true }
+func (e *testTimeoutError) Temporary() bool { return true }
+
+func TestClassify1(t *testing.T) {
+	_, errNotExist := os.Open("/path/to/not/exist")
+
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{err: nil, want: codes.OK},
+		{err: errNotExist, want: codes.NotFound},
+		{err: os.ErrExist, want: codes.AlreadyExists},
+		{err: os.ErrPermission, want: codes.PermissionDenied},
+		{err: &net.OpError{Op: "dial", Err: &testTimeoutError{}}, want: codes.DeadlineExceeded},
+		{err: io.EOF, want: codes.Unavailable},
+		{err: context.Canceled, want: codes.Canceled},
+		{err: W(fmt.Errorf("wrap: %w", errNotExist)), want: codes.NotFound},
+		// いずれにもマッチしない場合はラップ先のコード
+		{err: ErrAborted, want: codes.Aborted},
+		{err: errors.New("plain"), want: codes.Unknown},
+	}
+	for _, test := range tests {
+		got := Classify(test.err)
+		if got != test.want {
+			t.Errorf("[%v] got: %s, want: %s", test.err, got, test.want)
+			return
+		}
+	}
+}
+
+func TestClassify2(t *testing.T) {
+	backup := Classifiers
+	defer func() { Classifiers = backup }()
+
+	errCustom := errors.New("custom")
+	Classifiers = []func(error) (codes.Code, bool){
+		func(err error) (codes.Code, bool) {
+			return codes.ResourceExhausted, errors.Is(err, errCustom)
+		},
+		func(err error) (codes.Code, bool) {
+			return codes.Internal, true
+		},
+	}
+
+	// 先頭から評価し, 最初にマッチしたルールが採用される
+	if got := Classify(errCustom); got != codes.ResourceExhausted {
+		t.Errorf("\n  got: %s\n  want: %s", got, codes.ResourceExhausted)
+		return
+	}
+	if got := Classify(io.EOF); got != codes.Internal {
+		t.Errorf("\n  got: %s\n  want: %s", got, codes.Internal)
+		return
+	}
+}
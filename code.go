@@ -0,0 +1,58 @@
+package ers
+
+import (
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+)
+
+// CodeFromString は, codes.Code.String() の文字列表現から codes.Code を返す.
+// "13" のような数値の文字列にも対応する.
+// 未知の文字列の場合は codes.Unknown と false を返す.
+func CodeFromString(s string) (codes.Code, bool) {
+	return codeFromString(s, func(a, b string) bool { return a == b })
+}
+
+// CodeFromStringFold は, 大文字小文字を区別せずに CodeFromString と同じ変換を行う.
+func CodeFromStringFold(s string) (codes.Code, bool) {
+	return codeFromString(s, strings.EqualFold)
+}
+
+func codeFromString(s string, equal func(a, b string) bool) (codes.Code, bool) {
+	for code := codes.OK; code <= codes.Unauthenticated; code++ {
+		if equal(code.String(), s) {
+			return code, true
+		}
+	}
+	if n, err := strconv.ParseUint(s, 10, 32); err == nil && n <= uint64(codes.Unauthenticated) {
+		return codes.Code(n), true
+	}
+	return codes.Unknown, false
+}
+
+// codeStrings は, 定義済みのコードの文字列表現. パッケージの初期化時に一度だけ文字列化する.
+var codeStrings = func() [codes.Unauthenticated + 1]string {
+	var v [codes.Unauthenticated + 1]string
+	for code := codes.OK; code <= codes.Unauthenticated; code++ {
+		v[code] = code.String()
+	}
+	return v
+}()
+
+// codeString は, code の文字列表現を codes.Code.String() と同じ形式で返す.
+// 定義済みのコードはアロケーションせずに返す.
+// 未知のコードはリモートから任意の値を受け取り得るため, キャッシュせずにその都度文字列化する.
+func codeString(code codes.Code) string {
+	if code <= codes.Unauthenticated {
+		return codeStrings[code]
+	}
+	return code.String()
+}
+
+// CodeString は, ラップ先まで辿って解決したコードの文字列表現を返す.
+// ログなどで大量に呼び出してもアロケーションしないよう, 定義済みのコードは事前に文字列化したものを返す.
+// 未知のコードの場合も codes.Code.String() と同じく "Code(17)" の形式で返す.
+func (e *Error) CodeString() string {
+	return codeString(e.Code())
+}
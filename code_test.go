@@ -0,0 +1,88 @@
+package ers
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestCodeFromString1(t *testing.T) {
+	// 全コードの往復変換
+	for code := codes.OK; code <= codes.Unauthenticated; code++ {
+		for _, s := range []string{code.String(), strconv.Itoa(int(code))} {
+			got, ok := CodeFromString(s)
+			if !ok || got != code {
+				t.Errorf("[%s]\n  got: %s, %t\n  want: %s, true", s, got, ok, code)
+				return
+			}
+		}
+	}
+}
+
+func TestCodeFromString2(t *testing.T) {
+	tests := []string{"", "internal", "INTERNAL", "Unknown ", "17", "-1", "Code(17)", "NotExists"}
+	for _, test := range tests {
+		got, ok := CodeFromString(test)
+		if ok || got != codes.Unknown {
+			t.Errorf("[%s]\n  got: %s, %t\n  want: %s, false", test, got, ok, codes.Unknown)
+			return
+		}
+	}
+}
+
+func TestCodeFromStringFold1(t *testing.T) {
+	for code := codes.OK; code <= codes.Unauthenticated; code++ {
+		for _, s := range []string{strings.ToLower(code.String()), strings.ToUpper(code.String())} {
+			got, ok := CodeFromStringFold(s)
+			if !ok || got != code {
+				t.Errorf("[%s]\n  got: %s, %t\n  want: %s, true", s, got, ok, code)
+				return
+			}
+		}
+	}
+	if got, ok := CodeFromStringFold("not_exists"); ok || got != codes.Unknown {
+		t.Errorf("\n  got: %s, %t\n  want: %s, false", got, ok, codes.Unknown)
+		return
+	}
+}
+
+func TestCodeString1(t *testing.T) {
+	for code := codes.OK; code <= codes.Unauthenticated+2; code++ {
+		err := W(New(code, "", ""))
+		if got, want := err.(*Error).CodeString(), code.String(); got != want {
+			t.Errorf("\n  got: %s\n  want: %s", got, want)
+			return
+		}
+	}
+
+	err := New(codes.Code(100), "", "")
+	if got, want := err.CodeString(), "Code(100)"; got != want {
+		t.Errorf("\n  got: %s\n  want: %s", got, want)
+		return
+	}
+
+	// 定義済みのコードはアロケーションしない
+	err = New(codes.NotFound, "", "")
+	if got := testing.AllocsPerRun(100, func() { _ = err.CodeString() }); got != 0 {
+		t.Errorf("\n  got: %v\n  want: %v", got, 0)
+		return
+	}
+}
+
+func BenchmarkCodeString(b *testing.B) {
+	err := W(New(codes.NotFound, "", ""))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = err.(*Error).CodeString()
+	}
+}
+
+func BenchmarkCodeStringWithoutCache(b *testing.B) {
+	err := W(New(codes.NotFound, "", ""))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = err.(*Error).Code().String()
+	}
+}
@@ -0,0 +1,43 @@
+package ers
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CodeReason は, Collect で収集したラップチェーンの層ごとの code と reason.
+type CodeReason struct {
+	Code   codes.Code
+	Reason string
+}
+
+// Collect は, err のラップチェーン中に現れた code と reason を外側の層から順に返す.
+// *Error はラップ元のエラーと, ラップ時に code や reason を指定した層を収集する.
+// *Error 以外で GRPCStatus を実装したエラーは, status の code と ErrorInfo の reason を収集する.
+func Collect(err error) []CodeReason {
+	var collected []CodeReason
+	Walk(err, func(err error) bool {
+		switch v := err.(type) {
+		case *Error:
+			if v.hasOwnCode() {
+				collected = append(collected, CodeReason{Code: v.Code(), Reason: v.Reason()})
+			}
+		case interface{ GRPCStatus() *status.Status }:
+			s := v.GRPCStatus()
+			collected = append(collected, CodeReason{Code: s.Code(), Reason: statusReason(s)})
+		}
+		return true
+	})
+	return collected
+}
+
+// statusReason は, s の details に含まれる ErrorInfo の reason を返す.
+func statusReason(s *status.Status) string {
+	for _, detail := range s.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.GetReason()
+		}
+	}
+	return ""
+}
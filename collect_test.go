@@ -0,0 +1,51 @@
+package ers
+
+import (
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCollect1(t *testing.T) {
+	tests := []struct {
+		err  error
+		want []CodeReason
+	}{
+		{err: nil, want: nil},
+		{err: &testErrorPtr{}, want: nil},
+		{err: W(W(ErrNotFound)), want: []CodeReason{{Code: codes.NotFound, Reason: "NotFound"}}},
+		{
+			err: W(
+				fmt.Errorf("wrap: %w", W(status.Error(codes.Unavailable, ""), WithCode(codes.Internal), WithReason("Database"))),
+				WithCode(codes.NotFound),
+			),
+			want: []CodeReason{
+				{Code: codes.NotFound, Reason: "Database"},
+				{Code: codes.Internal, Reason: "Database"},
+				{Code: codes.Unavailable, Reason: ""},
+			},
+		},
+		{
+			err: W(ErrNotFound.GRPCStatus().Err()),
+			want: []CodeReason{
+				{Code: codes.NotFound, Reason: "NotFound"},
+			},
+		},
+		{
+			err: Join(ErrNotFound, W(ErrInternal)),
+			want: []CodeReason{
+				{Code: codes.NotFound, Reason: "NotFound"},
+				{Code: codes.Internal, Reason: "Internal"},
+			},
+		},
+	}
+	for _, test := range tests {
+		got := Collect(test.err)
+		if fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("\n  got: %v\n  want: %v", got, test.want)
+			return
+		}
+	}
+}
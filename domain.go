@@ -0,0 +1,20 @@
+package ers
+
+import (
+	"sync/atomic"
+)
+
+// defaultDomain は, SetDefaultDomain で設定されたパッケージ全体のデフォルトの domain.
+var defaultDomain atomic.Value
+
+// SetDefaultDomain は, domain が設定されていないエラーの Domain が返すデフォルトの domain を設定する.
+// 初期化時に一度だけ呼び出すことを想定しているが, 並行に呼び出しても競合しない.
+func SetDefaultDomain(domain string) {
+	defaultDomain.Store(domain)
+}
+
+// getDefaultDomain は, SetDefaultDomain で設定された domain を返す.
+func getDefaultDomain() string {
+	v, _ := defaultDomain.Load().(string)
+	return v
+}
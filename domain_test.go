@@ -0,0 +1,54 @@
+package ers
+
+import (
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestSetDefaultDomain1(t *testing.T) {
+	defer SetDefaultDomain("")
+	SetDefaultDomain("default.example.com")
+
+	tests := []struct {
+		err  *Error
+		want string
+	}{
+		{err: New(codes.NotFound, "NotFound", ""), want: "default.example.com"},
+		{err: New(codes.NotFound, "NotFound", "").WithDomain("example.com"), want: "example.com"},
+		{err: W(New(codes.NotFound, "NotFound", "").WithDomain("example.com")).(*Error), want: "example.com"},
+		{err: W(&testErrorPtr{}).(*Error), want: "default.example.com"},
+		{err: W(ErrNotFound).(*Error).WithDomain("outer.example.com"), want: "outer.example.com"},
+	}
+	for _, test := range tests {
+		if got := test.err.Domain(); got != test.want {
+			t.Errorf("\n  got: %s\n  want: %s", got, test.want)
+			return
+		}
+		// デフォルトの domain も ErrorInfo に反映される
+		if got := errorInfoOf(t, test.err.GRPCStatus()).GetDomain(); got != test.want {
+			t.Errorf("\n  got: %s\n  want: %s", got, test.want)
+			return
+		}
+	}
+}
+
+func TestSetDefaultDomain2(t *testing.T) {
+	defer SetDefaultDomain("")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			SetDefaultDomain("example.com")
+			_ = ErrNotFound.Domain()
+		}()
+	}
+	wg.Wait()
+	if got := ErrNotFound.Domain(); got != "example.com" {
+		t.Errorf("\n  got: %s\n  want: %s", got, "example.com")
+		return
+	}
+}
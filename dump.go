@@ -0,0 +1,222 @@
+package ers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxDepthReached は, MaxDepth を超えてネストした値の代わりに出力する文字列.
+	maxDepthReached = "<max depth reached>"
+	// circularReference は, 循環参照している値の代わりに出力する文字列.
+	circularReference = "<circular reference>"
+	// sanitizedValue は, 機密情報を含むフィールドの代わりに出力する文字列.
+	sanitizedValue = "***"
+)
+
+// dumpValue は, v を maxDepth 段までネストを展開して文字列にする.
+// sanitizeKeys に一致する名前のフィールドやマップのキーの値は sanitizedValue に置き換える.
+// maxDepth が 0 以下かつ sanitizeKeys が無い場合や, v が単純型の場合は展開せずに %+v で出力する.
+func dumpValue(v any, maxDepth int, sanitizeKeys ...string) string {
+	if isSimple(v) || maxDepth <= 0 && len(sanitizeKeys) == 0 {
+		return fmt.Sprintf("%+v", v)
+	}
+	d := &dumper{
+		maxDepth:     maxDepth,
+		sanitizeKeys: sanitizeKeys,
+		visited:      map[uintptr]bool{},
+	}
+	d.dump(reflect.ValueOf(v), 0)
+	return d.buf.String()
+}
+
+// jsonValue は, v を JSON にする.
+// JSON にできない場合は型名の文字列にする.
+// sanitizeKeys に一致する名前のオブジェクトのキーの値は sanitizedValue に置き換える.
+func jsonValue(v any, sanitizeKeys ...string) json.RawMessage {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprintf("%T", v))
+		return b
+	}
+	if len(sanitizeKeys) == 0 {
+		return b
+	}
+
+	// フィールド名は JSON のキーとして比較するため, 一度デコードしてから置き換える
+	// float64 にすると 2^53 を超える整数の精度が落ちるため, 数値は json.Number のまま扱う
+	var decoded any
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return b
+	}
+	b, _ = json.Marshal(sanitizeJSON(decoded, sanitizeKeys))
+	return b
+}
+
+// sanitizeJSON は, JSON をデコードした v のうち keys に一致するキーの値を sanitizedValue に置き換える.
+func sanitizeJSON(v any, keys []string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, value := range v {
+			if matchKey(k, keys) {
+				v[k] = sanitizedValue
+				continue
+			}
+			v[k] = sanitizeJSON(value, keys)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = sanitizeJSON(value, keys)
+		}
+	}
+	return v
+}
+
+// isSimple は, v が展開するネストを持たない単純型の場合に true を返す.
+func isSimple(v any) bool {
+	if _, ok := v.(error); ok {
+		return true
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Invalid, reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	}
+	return false
+}
+
+type dumper struct {
+	buf          strings.Builder
+	maxDepth     int
+	sanitizeKeys []string
+	// 展開中のポインタ
+	visited map[uintptr]bool
+}
+
+func (d *dumper) dump(v reflect.Value, depth int) {
+	if !v.IsValid() {
+		d.buf.WriteString("<nil>")
+		return
+	}
+
+	// error や fmt.Stringer は独自の文字列表現を使う
+	if v.CanInterface() {
+		switch v.Interface().(type) {
+		case error, fmt.Stringer:
+			if v.Kind() != reflect.Pointer || !v.IsNil() {
+				fmt.Fprintf(&d.buf, "%+v", v)
+				return
+			}
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			d.buf.WriteString("<nil>")
+			return
+		}
+		d.dump(v.Elem(), depth)
+	case reflect.Pointer:
+		if v.IsNil() {
+			d.buf.WriteString("<nil>")
+			return
+		}
+		if d.visited[v.Pointer()] {
+			d.buf.WriteString(circularReference)
+			return
+		}
+		d.visited[v.Pointer()] = true
+		defer delete(d.visited, v.Pointer())
+
+		d.buf.WriteString("&")
+		d.dump(v.Elem(), depth)
+	case reflect.Struct:
+		if d.enter(depth) {
+			return
+		}
+		d.buf.WriteString("{")
+		for i := 0; i < v.NumField(); i++ {
+			if i > 0 {
+				d.buf.WriteString(" ")
+			}
+			name := v.Type().Field(i).Name
+			d.buf.WriteString(name)
+			d.buf.WriteString(":")
+			if d.sanitize(name) {
+				d.buf.WriteString(sanitizedValue)
+				continue
+			}
+			d.dump(v.Field(i), depth+1)
+		}
+		d.buf.WriteString("}")
+	case reflect.Slice, reflect.Array:
+		if d.enter(depth) {
+			return
+		}
+		d.buf.WriteString("[")
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				d.buf.WriteString(" ")
+			}
+			d.dump(v.Index(i), depth+1)
+		}
+		d.buf.WriteString("]")
+	case reflect.Map:
+		if d.enter(depth) {
+			return
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		d.buf.WriteString("map[")
+		for i, key := range keys {
+			if i > 0 {
+				d.buf.WriteString(" ")
+			}
+			d.dump(key, depth+1)
+			d.buf.WriteString(":")
+			if key.Kind() == reflect.String && d.sanitize(key.String()) {
+				d.buf.WriteString(sanitizedValue)
+				continue
+			}
+			d.dump(v.MapIndex(key), depth+1)
+		}
+		d.buf.WriteString("]")
+	default:
+		fmt.Fprintf(&d.buf, "%+v", v)
+	}
+}
+
+// enter は, depth 段目の値を展開できない場合に代わりの文字列を出力して true を返す.
+func (d *dumper) enter(depth int) bool {
+	if d.maxDepth <= 0 || depth < d.maxDepth {
+		return false
+	}
+	d.buf.WriteString(maxDepthReached)
+	return true
+}
+
+// sanitize は, name の値を伏せる場合に true を返す.
+func (d *dumper) sanitize(name string) bool {
+	return matchKey(name, d.sanitizeKeys)
+}
+
+// matchKey は, name が keys のいずれかと大文字小文字を区別せずに一致する場合に true を返す.
+func matchKey(name string, keys []string) bool {
+	for _, key := range keys {
+		if strings.EqualFold(name, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package ers
+
+import (
+	"errors"
+	"testing"
+)
+
+type testDumpNode struct {
+	Name  string
+	Child *testDumpNode
+}
+
+func TestDumpValue1(t *testing.T) {
+	deep := &testDumpNode{Name: "a", Child: &testDumpNode{Name: "b", Child: &testDumpNode{Name: "c"}}}
+
+	tests := []struct {
+		src      any
+		maxDepth int
+		want     string
+	}{
+		{src: deep, maxDepth: 0, want: "&{Name:a Child:0x"},
+		{src: deep, maxDepth: 1, want: "&{Name:a Child:&<max depth reached>}"},
+		{src: deep, maxDepth: 2, want: "&{Name:a Child:&{Name:b Child:&<max depth reached>}}"},
+		{src: deep, maxDepth: 3, want: "&{Name:a Child:&{Name:b Child:&{Name:c Child:<nil>}}}"},
+		{src: []any{1, []int{2, 3}}, maxDepth: 1, want: "[1 <max depth reached>]"},
+		{src: map[string]int{"b": 2, "a": 1}, maxDepth: 1, want: "map[a:1 b:2]"},
+		{src: struct{ Err error }{Err: errors.New("error")}, maxDepth: 1, want: "{Err:error}"},
+		{src: nil, maxDepth: 1, want: "<nil>"},
+	}
+	for _, test := range tests {
+		got := dumpValue(test.src, test.maxDepth)
+		if test.maxDepth == 0 {
+			got = got[:len(test.want)]
+		}
+		if got != test.want {
+			t.Errorf("\n  got: %s\n  want: %s", got, test.want)
+			return
+		}
+	}
+}
+
+func TestTraceDump2(t *testing.T) {
+	fixNow(t)
+
+	type Inner struct{ Value int }
+	type Outer struct{ Inner Inner }
+
+	trace := NewTrace("text")
+	trace.Values = []any{Outer{Inner: Inner{Value: 1}}}
+
+	// MaxDepth が 0 の場合は従来通り制限しない
+	if got, want := trace.Dump(), "2022-06-27T12:00:00Z text\n{Inner:{Value:1}}"; got != want {
+		t.Errorf("\n  got: %s\n  want: %s", got, want)
+		return
+	}
+
+	trace.MaxDepth = 1
+	if got, want := trace.Dump(), "2022-06-27T12:00:00Z text\n{Inner:<max depth reached>}"; got != want {
+		t.Errorf("\n  got: %s\n  want: %s", got, want)
+		return
+	}
+
+	// NewTrace や With でコピーしても深さの指定は引き継がれる
+	if got := NewTrace(trace).With("k", "v").MaxDepth; got != 1 {
+		t.Errorf("\n  got: %d\n  want: %d", got, 1)
+		return
+	}
+}
+
+func TestDumpValue2(t *testing.T) {
+	type ID int
+	tests := []struct {
+		src  any
+		want string
+	}{
+		{src: "text", want: "text"},
+		{src: 1, want: "1"},
+		{src: ID(2), want: "2"},
+		{src: 1.5, want: "1.5"},
+		{src: true, want: "true"},
+		{src: errors.New("error"), want: "error"},
+		{src: nil, want: "<nil>"},
+	}
+	for _, test := range tests {
+		// 単純型は深さや伏せ字の指定に関わらず %+v と同じ出力になる
+		for _, maxDepth := range []int{0, 1} {
+			if got := dumpValue(test.src, maxDepth, "password"); got != test.want {
+				t.Errorf("\n  got: %s\n  want: %s", got, test.want)
+				return
+			}
+		}
+	}
+}
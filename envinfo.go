@@ -0,0 +1,31 @@
+package ers
+
+import (
+	"os"
+	"strconv"
+)
+
+const (
+	// metadataKeyHost は, WithEnvInfo でホスト名を格納する Metadata のキー.
+	metadataKeyHost = "Host"
+	// metadataKeyPID は, WithEnvInfo でプロセス ID を格納する Metadata のキー.
+	metadataKeyPID = "PID"
+)
+
+// hostname は, ホスト名を返す. テストで差し替えられるよう変数として持つ.
+var hostname = os.Hostname
+
+// withEnvInfo は, metadata にホスト名とプロセス ID を追加して返す.
+// metadata が nil の場合は新しく生成する.
+func withEnvInfo(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	host, err := hostname()
+	if err != nil {
+		host = ""
+	}
+	metadata[metadataKeyHost] = host
+	metadata[metadataKeyPID] = strconv.Itoa(os.Getpid())
+	return metadata
+}
@@ -0,0 +1,55 @@
+package ers
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestWithEnvInfo1(t *testing.T) {
+	hostname = func() (string, error) { return "app-1", nil }
+	t.Cleanup(func() { hostname = os.Hostname })
+
+	pid := strconv.Itoa(os.Getpid())
+	tests := []struct {
+		err  *Error
+		want map[string]string
+	}{
+		{err: New(codes.Internal, "", "", WithEnvInfo()), want: map[string]string{"Host": "app-1", "PID": pid}},
+		{err: W(ErrInternal, WithEnvInfo(), WithMetadata(map[string]string{"table": "users"})).(*Error), want: map[string]string{"Host": "app-1", "PID": pid, "table": "users"}},
+		{err: W(ErrInternal).(*Error), want: map[string]string{}},
+	}
+	for _, test := range tests {
+		// GRPCStatus の Metadata にも反映される
+		got := errorInfoOf(t, test.err.GRPCStatus()).GetMetadata()
+		if len(got) != len(test.want) {
+			t.Errorf("\n  got: %v\n  want: %v", got, test.want)
+			return
+		}
+		for k, v := range test.want {
+			if got[k] != v {
+				t.Errorf("%s\n  got: %s\n  want: %s", k, got[k], v)
+				return
+			}
+		}
+	}
+}
+
+func TestWithEnvInfo2(t *testing.T) {
+	// ホスト名を取得できない場合は空文字にフォールバックする
+	hostname = func() (string, error) { return "ignored", errors.New("hostname") }
+	t.Cleanup(func() { hostname = os.Hostname })
+
+	got := W(ErrInternal, WithEnvInfo()).(*Error).Metadata()
+	if v, ok := got["Host"]; !ok || v != "" {
+		t.Errorf("\n  got: %q, %t\n  want: %q, %t", v, ok, "", true)
+		return
+	}
+	if got["PID"] != strconv.Itoa(os.Getpid()) {
+		t.Errorf("\n  got: %s\n  want: %d", got["PID"], os.Getpid())
+		return
+	}
+}
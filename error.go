@@ -17,14 +17,18 @@ package ers
 // Google 側での改善が行われたら対策する
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
+	"time"
 
 	"golang.org/x/xerrors"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 var (
@@ -32,24 +36,27 @@ var (
 	errWrap = New(codes.Unknown, "InternalWrap", "")
 
 	// gRPC のエラーに基づいたエラー
-	ErrCanceled           = /* HTTP: 499 gRPC:  1 */ New(codes.Canceled, "Canceled", "処理がキャンセルされました。")
-	ErrUnknown            = /* HTTP: 500 gRPC:  2 */ New(codes.Unknown, "Unknown", "不明なエラーが発生しました。")
-	ErrInvalidArgument    = /* HTTP: 400 gRPC:  3 */ New(codes.InvalidArgument, "InvalidArgument", "入力値が不正です。")
-	ErrDeadlineExceeded   = /* HTTP: 504 gRPC:  4 */ New(codes.DeadlineExceeded, "DeadlineExceeded", "処理がタイムアウトしました。")
-	ErrNotFound           = /* HTTP: 404 gRPC:  5 */ New(codes.NotFound, "NotFound", "存在しないデータへの参照が発生しています。")
-	ErrAlreadyExists      = /* HTTP: 409 gRPC:  6 */ New(codes.AlreadyExists, "AlreadyExists", "データが既に存在します。")
-	ErrPermissionDenied   = /* HTTP: 403 gRPC:  7 */ New(codes.PermissionDenied, "PermissionDenied", "必要な権限がありません。")
-	ErrResourceExhausted  = /* HTTP: 429 gRPC:  8 */ New(codes.ResourceExhausted, "ResourceExhausted", "処理限界を超えています。")
-	ErrFailedPrecondition = /* HTTP: 400 gRPC:  9 */ New(codes.FailedPrecondition, "FailedPrecondition", "必要な条件を満たしていません。")
-	ErrAborted            = /* HTTP: 409 gRPC: 10 */ New(codes.Aborted, "Aborted", "操作が中断されました。")
-	ErrOutOfRange         = /* HTTP: 400 gRPC: 11 */ New(codes.OutOfRange, "OutOfRange", "入力値が有効範囲外です。")
-	ErrUnimplemented      = /* HTTP: 501 gRPC: 12 */ New(codes.Unimplemented, "Unimplemented", "サポートされていません。")
-	ErrInternal           = /* HTTP: 500 gRPC: 13 */ New(codes.Internal, "Internal", "システム内部でエラーが発生しました。")
-	ErrUnavailable        = /* HTTP: 503 gRPC: 14 */ New(codes.Unavailable, "Unavailable", "システムは現在利用できません。")
-	ErrDataLoss           = /* HTTP: 500 gRPC: 15 */ New(codes.DataLoss, "DataLoss", "修復不能なデータの欠損が生じました。")
-	ErrUnauthenticated    = /* HTTP: 401 gRPC: 16 */ New(codes.Unauthenticated, "Unauthenticated", "認証できませんでした。")
+	ErrCanceled           = /* HTTP: 499 gRPC:  1 */ NewR(codes.Canceled, ReasonCanceled, "処理がキャンセルされました。")
+	ErrUnknown            = /* HTTP: 500 gRPC:  2 */ NewR(codes.Unknown, ReasonUnknown, "不明なエラーが発生しました。")
+	ErrInvalidArgument    = /* HTTP: 400 gRPC:  3 */ NewR(codes.InvalidArgument, ReasonInvalidArgument, "入力値が不正です。")
+	ErrDeadlineExceeded   = /* HTTP: 504 gRPC:  4 */ NewR(codes.DeadlineExceeded, ReasonDeadlineExceeded, "処理がタイムアウトしました。")
+	ErrNotFound           = /* HTTP: 404 gRPC:  5 */ NewR(codes.NotFound, ReasonNotFound, "存在しないデータへの参照が発生しています。")
+	ErrAlreadyExists      = /* HTTP: 409 gRPC:  6 */ NewR(codes.AlreadyExists, ReasonAlreadyExists, "データが既に存在します。")
+	ErrPermissionDenied   = /* HTTP: 403 gRPC:  7 */ NewR(codes.PermissionDenied, ReasonPermissionDenied, "必要な権限がありません。")
+	ErrResourceExhausted  = /* HTTP: 429 gRPC:  8 */ NewR(codes.ResourceExhausted, ReasonResourceExhausted, "処理限界を超えています。")
+	ErrFailedPrecondition = /* HTTP: 400 gRPC:  9 */ NewR(codes.FailedPrecondition, ReasonFailedPrecondition, "必要な条件を満たしていません。")
+	ErrAborted            = /* HTTP: 409 gRPC: 10 */ NewR(codes.Aborted, ReasonAborted, "操作が中断されました。")
+	ErrOutOfRange         = /* HTTP: 400 gRPC: 11 */ NewR(codes.OutOfRange, ReasonOutOfRange, "入力値が有効範囲外です。")
+	ErrUnimplemented      = /* HTTP: 501 gRPC: 12 */ NewR(codes.Unimplemented, ReasonUnimplemented, "サポートされていません。")
+	ErrInternal           = /* HTTP: 500 gRPC: 13 */ NewR(codes.Internal, ReasonInternal, "システム内部でエラーが発生しました。")
+	ErrUnavailable        = /* HTTP: 503 gRPC: 14 */ NewR(codes.Unavailable, ReasonUnavailable, "システムは現在利用できません。")
+	ErrDataLoss           = /* HTTP: 500 gRPC: 15 */ NewR(codes.DataLoss, ReasonDataLoss, "修復不能なデータの欠損が生じました。")
+	ErrUnauthenticated    = /* HTTP: 401 gRPC: 16 */ NewR(codes.Unauthenticated, ReasonUnauthenticated, "認証できませんでした。")
 )
 
+// maxUnwrapDepth は, ラップ先を辿る段数の上限.
+const maxUnwrapDepth = 100
+
 var (
 	// W 関数は, NewWrap 関数のエイリアス.
 	W = NewWrap
@@ -57,45 +64,121 @@ var (
 
 type Error struct {
 	error
-	code    codes.Code
-	reason  string
-	message string
-	trace   *Trace
-	frame   xerrors.Frame
-	domain  string
+	code     codes.Code
+	reason   string
+	message  string
+	trace    *Trace
+	frame    xerrors.Frame
+	stack    []uintptr
+	domain   string
+	metadata map[string]string
+	// ラップ時に明示的に指定されたコード
+	overrideCode *codes.Code
+	// ラップ時に明示的に指定された reason
+	overrideReason string
+	// GRPCStatus で LocalizedMessage を付与するロケール
+	locale string
+	// フィルタ用のタグ
+	tags []string
+	// GRPCStatus で Help として付与するリンク
+	help []*errdetails.Help_Link
+	// GRPCStatus で BadRequest として付与する入力値の違反
+	fieldViolations []*errdetails.BadRequest_FieldViolation
+	// GRPCStatus で QuotaFailure として付与するクォータの違反
+	quotaViolations []*errdetails.QuotaFailure_Violation
+	// GRPCStatus で RetryInfo として付与する再試行の間隔
+	retryDelay *time.Duration
+	// エラーを生成した時刻
+	createdAt time.Time
+	// NewR や Catalog で定義した番兵エラーかどうか. OldestAge や Fingerprint で発生箇所として扱わない
+	sentinel bool
+	// エラーを生成した呼び出し元のプログラムカウンタ. サンプリングしなかった場合も記録する
+	pc uintptr
+}
+
+// New は, エラーを生成する.
+// options の効果は各オプションの説明を参照. ラップ先の値を上書きするオプションは無視する.
+func New(code codes.Code, reason string, message string, options ...WrapOption) *Error {
+	return newError(code, reason, message, newWrapOptions(options))
+}
+
+// NewAuto は, code に対応する定義済みエラーのメッセージを message としたエラーを生成する.
+// 定義済みエラーが無い codes.OK などの場合は message を空にする.
+func NewAuto(code codes.Code, reason string) *Error {
+	return newError(code, reason, defaultMessage(code), wrapOptions{})
 }
 
-func New(code codes.Code, reason string, message string) *Error {
-	return &Error{
-		code:    code,
-		reason:  reason,
-		message: message,
-		frame:   xerrors.Caller(1),
-		trace:   NewTrace(""),
+// NewWithOptions は, options を適用したエラーを生成する. message は WithMessage で指定する.
+// options の効果は各オプションの説明を参照. ラップ先の値を上書きするオプションは無視する.
+func NewWithOptions(code codes.Code, reason string, options ...WrapOption) *Error {
+	o := newWrapOptions(options)
+	return newError(code, reason, o.Message, o)
+}
+
+// newError は, New 系の関数から呼び出され, その呼び出し元をフレームとして記録したエラーを生成する.
+// アロケーションを減らすため, trace は指定された場合のみ生成する.
+func newError(code codes.Code, reason string, message string, o wrapOptions) *Error {
+	e := &Error{
+		code:      code,
+		reason:    reason,
+		message:   message,
+		domain:    o.Domain,
+		locale:    o.Locale,
+		createdAt: nowFunc(),
+		pc:        caller(2 + o.Frame),
+		sentinel:  o.sentinel,
+	}
+	// サンプリングされなかった場合は trace と frame を記録しない
+	if o.sampled() {
+		e.frame = xerrors.Caller(2 + o.Frame)
+		e.stack = callers(2 + o.Frame)
+		if o.Trace != nil {
+			e.trace = NewTrace(o.Trace)
+		}
+	}
+	if o.Metadata != nil {
+		e.metadata = o.Metadata
 	}
+	if o.EnvInfo {
+		e.metadata = withEnvInfo(e.metadata)
+	}
+	e.tags = o.Tags
+	e.help = o.Help
+	e.fieldViolations = o.FieldViolations
+	e.quotaViolations = o.QuotaViolations
+	e.retryDelay = o.RetryDelay
+	// WithCode, WithReason, WithSnapshot はラップ先の値を上書きするためのものであり,
+	// 生成時に適用すると Code と Is の結果が食い違うため無視する
+	return notify(e)
 }
 
 // deperecated
 func (e *Error) New(v any) error {
 	err := &Error{
-		code:    e.code,
-		reason:  e.reason,
-		message: e.message,
-		frame:   xerrors.Caller(1),
-		trace:   NewTrace(v),
+		code:      e.code,
+		reason:    e.reason,
+		message:   e.message,
+		domain:    e.domain,
+		frame:     xerrors.Caller(1),
+		stack:     callers(1),
+		createdAt: nowFunc(),
+		trace:     NewTrace(v),
 	}
-	return err
+	return notify(err)
 }
 
 // recomended
 func (e *Error) WithTrace(v any) error {
-	err := &Error{
-		code:    e.code,
-		reason:  e.reason,
-		message: e.message,
-		frame:   xerrors.Caller(1),
-		trace:   NewTrace(v),
-	}
+	err := notify(&Error{
+		code:      e.code,
+		reason:    e.reason,
+		message:   e.message,
+		domain:    e.domain,
+		frame:     xerrors.Caller(1),
+		stack:     callers(1),
+		createdAt: nowFunc(),
+		trace:     NewTrace(v),
+	})
 	return err
 }
 
@@ -104,22 +187,64 @@ func NewWrap(err error, options ...WrapOption) error {
 		return nil
 	}
 
+	o := newWrapOptions(options)
 	v := &Error{
-		error:   err,
-		code:    errWrap.code,
-		reason:  errWrap.reason,
-		message: errWrap.message,
-		frame:   xerrors.Caller(1),
+		error:     err,
+		code:      errWrap.code,
+		reason:    errWrap.reason,
+		message:   errWrap.message,
+		createdAt: nowFunc(),
+		pc:        caller(1 + o.Frame),
 	}
-
-	o := wrapOptions{}
-	for _, option := range options {
-		option(&o)
+	// サンプリングされなかった場合は trace と frame を記録しない
+	if o.sampled() {
+		v.frame = xerrors.Caller(1 + o.Frame)
+		v.stack = callers(1 + o.Frame)
+		if o.Trace != nil {
+			v.trace = NewTrace(o.Trace)
+		}
 	}
-	if o.Trace != nil {
-		v.trace = NewTrace(o.Trace)
+	if o.Code != nil {
+		v.overrideCode = o.Code
+	} else if code, ok := contextCode(err); ok && !hasInnerCode(err) {
+		// ラップ先の *Error が明示的なコードを持つ場合はそちらを優先する
+		v.overrideCode = &code
 	}
-	return v
+	if o.Message != "" {
+		v.message = o.Message
+	}
+	if o.Reason != "" {
+		v.overrideReason = o.Reason
+	}
+	if o.Locale != "" {
+		v.locale = o.Locale
+	}
+	if o.Domain != "" {
+		v.domain = o.Domain
+	}
+	v.tags = o.Tags
+	v.help = o.Help
+	v.fieldViolations = o.FieldViolations
+	v.quotaViolations = o.QuotaViolations
+	v.retryDelay = o.RetryDelay
+	if o.Metadata != nil {
+		v.metadata = o.Metadata
+	}
+	if o.EnvInfo {
+		v.metadata = withEnvInfo(v.metadata)
+	}
+	if o.Snapshot {
+		v.snapshot()
+	}
+	return notify(v)
+}
+
+// snapshot は, ラップ先から解決した code/reason/message をラップした層に固定する.
+func (e *Error) snapshot() {
+	code := e.Code()
+	e.overrideCode = &code
+	e.overrideReason = e.Reason()
+	e.message = e.Message()
 }
 
 func Is(err error, target error) bool {
@@ -130,32 +255,256 @@ func As(err error, target interface{}) bool {
 	return errors.As(err, target)
 }
 
+// AsType は, err のラップチェーン中で最初に T に一致するエラーを返す.
+// 一致するエラーが無い場合は T のゼロ値と false を返す.
+//
+//	if e, ok := ers.AsType[*ers.Error](err); ok {
+//		...
+//	}
+func AsType[T error](err error) (T, bool) {
+	var target T
+	if As(err, &target) {
+		return target, true
+	}
+	var zero T
+	return zero, false
+}
+
+// IsCode は, ラップ先を辿って解決した err のコードが targets のいずれかに一致する場合に true を返す.
+// err が nil の場合は false を返す.
+func IsCode(err error, targets ...codes.Code) bool {
+	if err == nil {
+		return false
+	}
+	code := codeOf(err)
+	for _, target := range targets {
+		if code == target {
+			return true
+		}
+	}
+	return false
+}
+
+// Equal は, code/reason/message/domain がすべて一致する場合に true を返す.
+// trace や frame は実行環境に依存するため比較しない.
+// 両方 nil の場合は true, 片方のみ nil の場合は false を返す.
+func Equal(a, b *Error) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Code() == b.Code() &&
+		a.Reason() == b.Reason() &&
+		a.Message() == b.Message() &&
+		a.Domain() == b.Domain()
+}
+
+// Depth は, err から Unwrap を辿ったチェーンの長さを返す.
+// err が nil の場合は 0, ラップしていないエラーの場合は 1 を返す.
+// 循環参照がある場合は, 訪問済みのエラーに到達した時点で打ち切る.
+func Depth(err error) int {
+	visited := map[error]bool{}
+	depth := 0
+	for err != nil {
+		// 比較できない型はマップのキーにできないため訪問済みとして記録しない
+		if reflect.TypeOf(err).Comparable() {
+			if visited[err] {
+				break
+			}
+			visited[err] = true
+		}
+		depth++
+		err = errors.Unwrap(err)
+	}
+	return depth
+}
+
+// RootCause は, err から Unwrap を辿り, それ以上辿れなくなった最も内側のエラーを返す.
+// Unwrap() []error を実装したエラーは, 束ねたエラーのうち先頭のエラーを辿る.
+// err が nil の場合は nil を返す.
+// 循環参照がある場合は, 訪問済みのエラーに到達する直前のエラーを返す.
+func RootCause(err error) error {
+	visited := map[error]bool{}
+	for err != nil {
+		// 比較できない型はマップのキーにできないため訪問済みとして記録しない
+		if reflect.TypeOf(err).Comparable() {
+			visited[err] = true
+		}
+
+		var next error
+		switch v := err.(type) {
+		case interface{ Unwrap() error }:
+			next = v.Unwrap()
+		case interface{ Unwrap() []error }:
+			if errs := v.Unwrap(); len(errs) > 0 {
+				next = errs[0]
+			}
+		}
+		if next == nil || (reflect.TypeOf(next).Comparable() && visited[next]) {
+			return err
+		}
+		err = next
+	}
+	return nil
+}
+
+// Walk は, err から Unwrap を深さ優先で辿りながら各層のエラーに対して fn を呼び出す.
+// Unwrap() []error を実装したエラーは, 束ねたエラーを先頭から順に辿る.
+// fn が false を返した場合は走査を打ち切る.
+// 循環参照がある場合は, 訪問済みのエラーを再度辿らない.
+func Walk(err error, fn func(error) bool) {
+	visited := map[error]bool{}
+	stack := []error{err}
+	for len(stack) > 0 {
+		err := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if err == nil {
+			continue
+		}
+		// 比較できない型はマップのキーにできないため訪問済みとして記録しない
+		if reflect.TypeOf(err).Comparable() {
+			if visited[err] {
+				continue
+			}
+			visited[err] = true
+		}
+		if !fn(err) {
+			return
+		}
+
+		switch v := err.(type) {
+		case interface{ Unwrap() error }:
+			stack = append(stack, v.Unwrap())
+		case interface{ Unwrap() []error }:
+			// 先頭のエラーから辿るよう逆順に積む
+			errs := v.Unwrap()
+			for i := len(errs) - 1; i >= 0; i-- {
+				stack = append(stack, errs[i])
+			}
+		}
+	}
+}
+
+// contextCode は, context パッケージのエラーに対応する codes.Code を返す.
+func contextCode(err error) (codes.Code, bool) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled, true
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded, true
+	}
+	return codes.Unknown, false
+}
+
+// hasInnerCode は, err のチェーン上に独自のコードを持つ *Error が存在するかを返す.
+func hasInnerCode(err error) bool {
+	found := false
+	Walk(err, func(v error) bool {
+		if e, ok := v.(*Error); ok && (e.isSource() || e.overrideCode != nil) {
+			found = true
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// codeOf は, err をラップ先まで辿って解決した codes.Code を返す.
+// 最初に見つかった *Error, GRPCStatus を実装したエラー, Code() codes.Code を実装したエラーのコードを返す.
+func codeOf(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+	code := codes.Unknown
+	Walk(err, func(err error) bool {
+		switch v := err.(type) {
+		case *Error:
+			code = v.Code()
+		case interface{ GRPCStatus() *status.Status }:
+			code = v.GRPCStatus().Code()
+		case interface{ Code() codes.Code }:
+			code = v.Code()
+		default:
+			return true
+		}
+		return false
+	})
+	return code
+}
+
 func (e *Error) Unwrap() error {
 	return e.error
 }
 
+// Is は, target が gRPC の status の場合は code のみを比較する.
+// reason の一致も要求する場合は IsWithOptions に MatchStatusReason を指定する.
 func (e *Error) Is(target error) bool {
-	if err, ok := target.(*Error); ok && e.code == err.code && e.reason == err.reason {
-		return true
+	switch err := target.(type) {
+	case *Error:
+		return e.matchError(err, matchOptions{})
+	case interface{ GRPCStatus() *status.Status }:
+		return e.matchStatus(err.GRPCStatus(), false)
 	}
 	return false
 }
 
+// matchError は, target と code/reason が一致するかどうかを返す.
+// ラップ先は辿らず, 生成時の code/reason を比較する. o で比較しない項目を指定できる.
+func (e *Error) matchError(target *Error, o matchOptions) bool {
+	return (o.ignoreCode || e.code == target.code) && (o.ignoreReason || e.reason == target.reason)
+}
+
+// matchStatus は, s と code が一致するかどうかを返す.
+// matchesReason が true の場合は errdetails.ErrorInfo の reason の一致も要求する.
+func (e *Error) matchStatus(s *status.Status, matchesReason bool) bool {
+	if e.Code() != s.Code() {
+		return false
+	}
+	if !matchesReason {
+		return true
+	}
+	for _, detail := range s.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return e.Reason() == info.GetReason()
+		}
+	}
+	return e.Reason() == ""
+}
+
+// As は, target が **Error の場合に e のコピーを新しく割り当てて target に設定する.
+// target が元々指していた *Error は変更しないため, ErrInternal などの定義済みエラーを渡しても汚染されない.
 func (e *Error) As(target interface{}) bool {
 	if err, ok := target.(**Error); ok {
-		(*err).error = e.error
-		(*err).code = e.code
-		(*err).reason = e.reason
-		(*err).message = e.message
-		(*err).trace = e.trace
-		(*err).frame = e.frame
+		*err = e.clone()
 		return true
 	}
 	return false
 }
 
+// clone は, 全フィールドをコピーした新しい *Error を返す.
+// フィールドが増えてもコピー漏れが起きないよう構造体ごと代入する.
+func (e *Error) clone() *Error {
+	v := *e
+	return &v
+}
+
+// Format は, 書式動詞ごとに次の形式で出力する.
+//
+//	%s  Message. 空の場合は Error
+//	%q  %s の出力をクオートした文字列
+//	%v  Message
+//	%+v reason, message, trace と frame またはスタック. ラップ先も同様に出力する
 func (e *Error) Format(state fmt.State, rune rune) {
 	switch rune {
+	case 's', 'q':
+		text := e.Message()
+		if text == "" {
+			text = e.Error()
+		}
+		if rune == 'q' {
+			text = strconv.Quote(text)
+		}
+		state.Write([]byte(text))
+		return
 	case 'v':
 		switch {
 		case state.Flag('+'), state.Flag('#'):
@@ -169,132 +518,363 @@ func (e *Error) Format(state fmt.State, rune rune) {
 }
 
 func (e *Error) FormatError(p xerrors.Printer) (next error) {
-	if e.trace != nil {
+	// 制御用の reason はメッセージと組にならない限り表示しない
+	switch {
+	case e.reason != "" && e.message != "":
+		p.Printf("%s: %s", e.reason, e.message)
+	case e.message != "":
+		p.Print(e.message)
+	}
+	if e.trace != nil && e.trace.Text != "" {
+		if e.message != "" {
+			p.Print(": ")
+		}
 		p.Print(e.trace.Text)
 	}
-	e.frame.Format(p)
+	// スタックが記録されている場合は単一フレームより優先する
+	// 直接生成や復元されたエラーはフレームを持たないため出力しない
+	if len(e.stack) > 0 {
+		e.formatStack(p)
+	} else if e.frame != (xerrors.Frame{}) {
+		e.frame.Format(p)
+	}
 	return e.error
 }
 
+// Error は, message や内包するエラーから直接文字列を組み立てる.
+// Format や FormatError から Error を呼んでも再帰しないよう, fmt による書式化には依存しない.
 func (e *Error) Error() string {
-	// 内包するエラーがない場合は自身のメッセージを返す
-	if !Is(e, errWrap) {
-		return e.Message()
-	}
+	v := e
+	// 循環参照で無限ループしないよう, 辿る段数に上限を設ける
+	for i := 0; i < maxUnwrapDepth; i++ {
+		// 内包するエラーがない場合は自身のメッセージを返す
+		if !v.Is(errWrap) {
+			return v.text()
+		}
 
-	v, ok := e.error.(*Error)
-	if !ok {
-		// 内包するエラーが独自エラー型ではない場合は内包するエラーのエラー文字列を返す
-		return e.error.Error()
-	}
+		next, ok := v.error.(*Error)
+		if !ok {
+			// 内包するエラーが独自エラー型ではない場合は内包するエラーのエラー文字列を返す
+			return v.error.Error()
+		}
+
+		// 内包するエラーが nil の場合は自身のメッセージを返す
+		if v.unwrapedErrorIsNil() {
+			return v.text()
+		}
 
-	// 内包するエラーが nil の場合は自身のメッセージを返す
-	if e.unwrapedErrorIsNil() {
-		return e.Message()
+		// 内包するエラーが独自エラー型の場合は内包するエラーのエラー文字列を返す
+		v = next
 	}
+	return e.text()
+}
 
-	// 内包するエラーが独自エラー型の場合は内包するエラーのエラー文字列を返す
-	return v.Error()
+// text は, message を返す.
+// message が空の場合は reason を, reason も空の場合は code の名前を返す.
+func (e *Error) text() string {
+	if v := e.Message(); v != "" {
+		return v
+	}
+	if v := e.Reason(); v != "" {
+		return v
+	}
+	return e.Code().String()
 }
 
+// WithDomain は, domain を設定したコピーを返す.
+// ErrInternal などの共有されるエラーを変更しないよう, レシーバは変更しない.
 func (e *Error) WithDomain(domain string) *Error {
-	e.domain = domain
-	return e
+	v := *e
+	v.domain = domain
+	return &v
+}
+
+// WithCode は, reason や message を保ったまま code を差し替えたコピーを返す.
+// ErrInternal などの共有されるエラーを変更しないよう, レシーバは変更しない.
+func (e *Error) WithCode(code codes.Code) *Error {
+	v := *e
+	if v.isSource() {
+		v.code = code
+	} else {
+		v.overrideCode = &code
+	}
+	return &v
 }
 
 func (e *Error) GRPCStatus() *status.Status {
+	info := &errdetails.ErrorInfo{
+		Reason:   e.Reason(),
+		Domain:   e.Domain(),
+		Metadata: e.Metadata(),
+	}
+	// "Trace" キーは予約されており, 常に trace の内容が優先される
+	delete(info.Metadata, metadataKeyTrace)
+	if IncludeTraceInStatus && e.trace != nil && e.trace.Text != "" {
+		if info.Metadata == nil {
+			info.Metadata = map[string]string{}
+		}
+		info.Metadata[metadataKeyTrace] = truncate(e.trace.Text, MaxTraceMetadataBytes)
+	}
+
 	grpcStatus := status.New(e.Code(), e.Message())
-	grpcStatus, _ = grpcStatus.WithDetails(&errdetails.ErrorInfo{
-		Reason: e.Reason(),
-		Domain: e.Domain(),
-	})
+	// codes.OK など details を付与できない場合は details 無しの status を返す
+	if v, err := grpcStatus.WithDetails(info); err == nil {
+		grpcStatus = v
+	}
+	if lang := e.Locale(); lang != "" {
+		localized := &errdetails.LocalizedMessage{
+			Locale:  lang,
+			Message: e.LocalizedMessage(lang),
+		}
+		if v, err := grpcStatus.WithDetails(localized); err == nil {
+			grpcStatus = v
+		}
+	}
+	if violations := e.FieldViolations(); len(violations) > 0 {
+		if v, err := grpcStatus.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); err == nil {
+			grpcStatus = v
+		}
+	}
+	if violations := e.QuotaViolations(); len(violations) > 0 {
+		if v, err := grpcStatus.WithDetails(&errdetails.QuotaFailure{Violations: violations}); err == nil {
+			grpcStatus = v
+		}
+	}
+	if d, ok := retryDelayOf(e); ok {
+		if v, err := grpcStatus.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(d)}); err == nil {
+			grpcStatus = v
+		}
+	}
+	if links := helpLinks(e); len(links) > 0 {
+		if v, err := grpcStatus.WithDetails(&errdetails.Help{Links: links}); err == nil {
+			grpcStatus = v
+		}
+	}
 	return grpcStatus
 }
 
+// Code は, ラップ先まで辿って解決した codes.Code を返す.
+// 優先順位は以下の通り.
+//
+//  1. ラップチェーン中で最も近い *Error の明示的なコード (WithCode などで指定したコードやラップ元のコード)
+//  2. *Error 以外で最も近い GRPCStatus を実装したエラーの status のコード
+//  3. 同じく Code() codes.Code を実装したエラーのコード
+//
+// GRPCStatus と Code() の両方を実装したエラーでは GRPCStatus を優先する.
+// いずれも無い場合は codes.Unknown を返す.
 func (e *Error) Code() codes.Code {
-	if e.isSource() {
-		return e.code
+	var external error
+	var err error = e
+	// 自己ラップなどの循環参照で無限ループしないよう, 辿る段数に上限を設ける
+	for i := 0; err != nil && i < maxUnwrapDepth; i++ {
+		switch v := err.(type) {
+		case *Error:
+			if v.overrideCode != nil {
+				return *v.overrideCode
+			}
+			if v.isSource() {
+				return v.code
+			}
+		case interface{ GRPCStatus() *status.Status }, interface{ Code() codes.Code }:
+			// より内側に *Error の明示的なコードがあればそちらを優先するため, 最後に解決する
+			if external == nil {
+				external = v
+			}
+		}
+		err = errors.Unwrap(err)
 	}
 
-	if err, ok := e.error.(interface{ GRPCStatus() *status.Status }); ok {
-		return err.GRPCStatus().Code()
-	}
-	if err, ok := e.error.(interface{ Code() codes.Code }); ok {
-		return err.Code()
+	switch v := external.(type) {
+	case interface{ GRPCStatus() *status.Status }:
+		return v.GRPCStatus().Code()
+	case interface{ Code() codes.Code }:
+		return v.Code()
 	}
 	return codes.Unknown
 }
 
 func (e *Error) Message() string {
-	if e.isSource() {
-		return e.message
-	}
-
-	if err, ok := e.error.(interface{ Message() string }); ok {
-		return err.Message()
-	}
-	if err, ok := e.error.(interface{ GRPCStatus() *status.Status }); ok {
-		switch err.GRPCStatus().Code() {
-		case codes.Canceled:
-			return ErrCanceled.message
-		case codes.Unknown:
-			return ErrUnknown.message
-		case codes.InvalidArgument:
-			return ErrInvalidArgument.message
-		case codes.DeadlineExceeded:
-			return ErrDeadlineExceeded.message
-		case codes.NotFound:
-			return ErrNotFound.message
-		case codes.AlreadyExists:
-			return ErrAlreadyExists.message
-		case codes.PermissionDenied:
-			return ErrPermissionDenied.reason
-		case codes.ResourceExhausted:
-			return ErrResourceExhausted.message
-		case codes.FailedPrecondition:
-			return ErrFailedPrecondition.message
-		case codes.Aborted:
-			return ErrAborted.message
-		case codes.OutOfRange:
-			return ErrOutOfRange.message
-		case codes.Unimplemented:
-			return ErrUnimplemented.message
-		case codes.Internal:
-			return ErrInternal.message
-		case codes.Unavailable:
-			return ErrUnavailable.message
-		case codes.DataLoss:
-			return ErrDataLoss.message
-		case codes.Unauthenticated:
-			return ErrUnauthenticated.message
+	v := e
+	// 循環参照で無限ループしないよう, 辿る段数に上限を設ける
+	for i := 0; i < maxUnwrapDepth; i++ {
+		if v.isSource() || v.message != "" {
+			return v.message
+		}
+
+		switch err := v.error.(type) {
+		case *Error:
+			v = err
+			continue
+		case interface{ Message() string }:
+			return err.Message()
+		}
+		// fmt.Errorf などを挟んでいても, 最も近い *Error にメッセージの解決を委譲する
+		if target, ok := nearestError(v.error); ok {
+			v = target
+			continue
 		}
+		if err, ok := v.error.(interface{ GRPCStatus() *status.Status }); ok {
+			return defaultMessage(err.GRPCStatus().Code())
+		}
+		return ""
 	}
 	return ""
 }
 
-func (e *Error) Reason() string {
-	if e.isSource() {
-		return e.reason
+// nearestError は, err のラップチェーン中で最も近い *Error を返す.
+// errors.As と異なり, 循環参照があっても訪問済みのエラーを再度辿らない.
+func nearestError(err error) (*Error, bool) {
+	var target *Error
+	Walk(err, func(err error) bool {
+		if v, ok := err.(*Error); ok {
+			target = v
+			return false
+		}
+		return true
+	})
+	return target, target != nil
+}
+
+// defaultMessage は, code に対応する定義済みエラーのメッセージを返す.
+// 対応する定義済みエラーが無い場合は空文字を返す.
+func defaultMessage(code codes.Code) string {
+	switch code {
+	case codes.Canceled:
+		return ErrCanceled.message
+	case codes.Unknown:
+		return ErrUnknown.message
+	case codes.InvalidArgument:
+		return ErrInvalidArgument.message
+	case codes.DeadlineExceeded:
+		return ErrDeadlineExceeded.message
+	case codes.NotFound:
+		return ErrNotFound.message
+	case codes.AlreadyExists:
+		return ErrAlreadyExists.message
+	case codes.PermissionDenied:
+		return ErrPermissionDenied.message
+	case codes.ResourceExhausted:
+		return ErrResourceExhausted.message
+	case codes.FailedPrecondition:
+		return ErrFailedPrecondition.message
+	case codes.Aborted:
+		return ErrAborted.message
+	case codes.OutOfRange:
+		return ErrOutOfRange.message
+	case codes.Unimplemented:
+		return ErrUnimplemented.message
+	case codes.Internal:
+		return ErrInternal.message
+	case codes.Unavailable:
+		return ErrUnavailable.message
+	case codes.DataLoss:
+		return ErrDataLoss.message
+	case codes.Unauthenticated:
+		return ErrUnauthenticated.message
 	}
-	err, ok := e.error.(interface{ Reason() string })
-	if ok {
-		return err.Reason()
+	return ""
+}
+
+// Reason は, ラップ先まで辿って解決した reason を返す.
+// WithReason で指定された reason があれば, 外側の層のものを優先する.
+func (e *Error) Reason() string {
+	v := e
+	// 循環参照で無限ループしないよう, 辿る段数に上限を設ける
+	for i := 0; i < maxUnwrapDepth; i++ {
+		if v.overrideReason != "" {
+			return v.overrideReason
+		}
+		if v.isSource() {
+			return v.reason
+		}
+		switch err := v.error.(type) {
+		case *Error:
+			v = err
+			continue
+		case interface{ Reason() string }:
+			return err.Reason()
+		}
+		// fmt.Errorf などを挟んでいても, Code や Message と同じく最も近い *Error に reason の解決を委譲する
+		if target, ok := nearestError(v.error); ok {
+			v = target
+			continue
+		}
+		if err, ok := v.error.(interface{ GRPCStatus() *status.Status }); ok {
+			return statusReason(err.GRPCStatus())
+		}
+		return ""
 	}
 	return ""
 }
 
+// Domain は, 自身に設定された domain, ラップ先の domain, SetDefaultDomain で設定された domain の順に解決して返す.
 func (e *Error) Domain() string {
-	if e.isSource() {
-		return e.domain
+	v := e
+	// 循環参照で無限ループしないよう, 辿る段数に上限を設ける
+	for i := 0; i < maxUnwrapDepth; i++ {
+		if v.domain != "" {
+			return v.domain
+		}
+		if v.isSource() {
+			break
+		}
+		next, ok := v.error.(*Error)
+		if !ok {
+			if err, ok := v.error.(interface{ Domain() string }); ok {
+				if domain := err.Domain(); domain != "" {
+					return domain
+				}
+			}
+			break
+		}
+		v = next
+	}
+	return getDefaultDomain()
+}
+
+// Metadata は, ラップ先のメタデータに自身のメタデータをマージしたコピーを返す.
+// 同じキーの場合は外側のメタデータが優先される.
+func (e *Error) Metadata() map[string]string {
+	var inner map[string]string
+	layers := []*Error{}
+	// 循環参照で無限ループしないよう, 辿る段数に上限を設ける
+	for v := e; len(layers) < maxUnwrapDepth; {
+		layers = append(layers, v)
+		if v.isSource() {
+			break
+		}
+		next, ok := v.error.(*Error)
+		if !ok {
+			if err, ok := v.error.(interface{ Metadata() map[string]string }); ok {
+				inner = err.Metadata()
+			}
+			break
+		}
+		v = next
+	}
+
+	var v map[string]string
+	for k, m := range inner {
+		if v == nil {
+			v = map[string]string{}
+		}
+		v[k] = m
 	}
-	if err, ok := e.error.(interface{ Domain() string }); ok {
-		return err.Domain()
+	// 外側のメタデータが優先されるよう内側の層から順にマージする
+	for i := len(layers) - 1; i >= 0; i-- {
+		for k, m := range layers[i].metadata {
+			if v == nil {
+				v = map[string]string{}
+			}
+			v[k] = m
+		}
 	}
-	return ""
+	return v
 }
 
 func (e *Error) isSource() bool {
-	return !Is(e, errWrap) || e.unwrapedErrorIsNil()
+	// ラップ先を辿らずに自身が制御用のエラーかどうかを判定する
+	return !e.Is(errWrap) || e.unwrapedErrorIsNil()
 }
 
 func (e *Error) unwrapedErrorIsNil() bool {
@@ -1,9 +1,17 @@
 package ers
 
 import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
 	"testing"
+	"time"
 
+	"golang.org/x/xerrors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestNewError1(t *testing.T) {
@@ -155,3 +163,1045 @@ func TestIs1(t *testing.T) {
 		}
 	}
 }
+
+func TestFormatError1(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "trace なし", err: New(codes.Internal, "reason", "message"), want: "reason: message"},
+		{name: "message のみ", err: New(codes.Internal, "", "message"), want: "message"},
+		{name: "trace あり", err: New(codes.Internal, "reason", "message").WithTrace("trace"), want: "reason: message: trace"},
+		{name: "ラップ", err: W(New(codes.Internal, "reason", "message"), WithTrace("trace")), want: "trace:\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := fmt.Sprintf("%+v", test.err)
+			if !strings.HasPrefix(got, test.want) {
+				t.Errorf("\n  got: %s\n  want prefix: %s", got, test.want)
+				return
+			}
+			// %+v ではスタックトレースが続く
+			if !strings.Contains(got, "error_test.go") {
+				t.Errorf("\n  got: %s\n  want: contains frame", got)
+				return
+			}
+		})
+	}
+}
+
+func TestMessage1(t *testing.T) {
+	tests := []*Error{
+		ErrCanceled,
+		ErrUnknown,
+		ErrInvalidArgument,
+		ErrDeadlineExceeded,
+		ErrNotFound,
+		ErrAlreadyExists,
+		ErrPermissionDenied,
+		ErrResourceExhausted,
+		ErrFailedPrecondition,
+		ErrAborted,
+		ErrOutOfRange,
+		ErrUnimplemented,
+		ErrInternal,
+		ErrUnavailable,
+		ErrDataLoss,
+		ErrUnauthenticated,
+	}
+	for _, test := range tests {
+		t.Run(test.reason, func(t *testing.T) {
+			// 外部の gRPC エラーをラップした場合も表示用メッセージが返る
+			err, ok := W(status.Error(test.code, "external")).(*Error)
+			if !ok {
+				t.Errorf("Failed type assertion")
+				return
+			}
+			got := err.Message()
+			if got != test.message {
+				t.Errorf("\n  got: %s\n  want: %s", got, test.message)
+				return
+			}
+		})
+	}
+}
+
+func TestNewWrap3(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{err: W(&testErrorPtr{}), want: codes.Unknown},
+		{err: W(&testErrorPtr{}, WithCode(codes.NotFound)), want: codes.NotFound},
+		{err: W(ErrInternal), want: codes.Internal},
+		{err: W(ErrInternal, WithCode(codes.NotFound)), want: codes.NotFound},
+		{err: W(W(ErrInternal, WithCode(codes.NotFound))), want: codes.NotFound},
+	}
+	for _, test := range tests {
+		got := test.err.(*Error).Code()
+		if got != test.want {
+			t.Errorf("[%v] got: %s, want: %s", test.err, got, test.want)
+			return
+		}
+	}
+}
+
+func TestWithCode1(t *testing.T) {
+	err := ErrInternal.WithCode(codes.Unavailable)
+
+	if err == ErrInternal {
+		t.Errorf("Expected a copy")
+		return
+	}
+	if err.Code() != codes.Unavailable {
+		t.Errorf("\n  got: %s\n  want: %s", err.Code(), codes.Unavailable)
+		return
+	}
+	if err.Reason() != ErrInternal.reason || err.Message() != ErrInternal.message {
+		t.Errorf("\n  got: %s, %s\n  want: %s, %s", err.Reason(), err.Message(), ErrInternal.reason, ErrInternal.message)
+		return
+	}
+
+	// 共有されるエラーは変更されない
+	if ErrInternal.Code() != codes.Internal {
+		t.Errorf("\n  got: %s\n  want: %s", ErrInternal.Code(), codes.Internal)
+		return
+	}
+}
+
+func TestWithCode2(t *testing.T) {
+	w := W(ErrInternal).(*Error)
+	err := w.WithCode(codes.NotFound)
+
+	if err.Code() != codes.NotFound {
+		t.Errorf("\n  got: %s\n  want: %s", err.Code(), codes.NotFound)
+		return
+	}
+	if w.Code() != codes.Internal {
+		t.Errorf("\n  got: %s\n  want: %s", w.Code(), codes.Internal)
+		return
+	}
+	if !Is(err, ErrInternal) {
+		t.Errorf("Expected to unwrap to %v", ErrInternal)
+		return
+	}
+}
+
+func TestWithDomain1(t *testing.T) {
+	err := ErrInternal.WithDomain("example.com")
+
+	if err == ErrInternal {
+		t.Errorf("Expected a copy")
+		return
+	}
+	if err.Domain() != "example.com" {
+		t.Errorf("\n  got: %s\n  want: %s", err.Domain(), "example.com")
+		return
+	}
+	if !Is(err, ErrInternal) {
+		t.Errorf("Expected to match %v", ErrInternal)
+		return
+	}
+
+	// 共有されるエラーは変更されない
+	if ErrInternal.Domain() != "" {
+		t.Errorf("\n  got: %s\n  want: %s", ErrInternal.Domain(), "")
+		return
+	}
+}
+
+func TestWithDomain2(t *testing.T) {
+	inner := ErrInternal.WithDomain("inner.example.com")
+	w := W(inner).(*Error)
+	err := w.WithDomain("outer.example.com")
+
+	if err.Domain() != "outer.example.com" {
+		t.Errorf("\n  got: %s\n  want: %s", err.Domain(), "outer.example.com")
+		return
+	}
+	if w.Domain() != "inner.example.com" {
+		t.Errorf("\n  got: %s\n  want: %s", w.Domain(), "inner.example.com")
+		return
+	}
+}
+
+func TestAs1(t *testing.T) {
+	src := New(codes.NotFound, "UserNotFound", "ユーザーが存在しません。").WithDomain("example.com")
+
+	dst := &Error{}
+	if !src.As(&dst) {
+		t.Errorf("Expected As to succeed")
+		return
+	}
+	if dst.Domain() != "example.com" {
+		t.Errorf("\n  got: %s\n  want: %s", dst.Domain(), "example.com")
+		return
+	}
+	if dst.Code() != src.Code() || dst.Reason() != src.Reason() || dst.Message() != src.Message() {
+		t.Errorf("\n  got: %s, %s, %s\n  want: %s, %s, %s", dst.Code(), dst.Reason(), dst.Message(), src.Code(), src.Reason(), src.Message())
+		return
+	}
+
+	var got *Error
+	if !As(W(src), &got) || got.Domain() != "example.com" {
+		t.Errorf("Expected As to find domain")
+		return
+	}
+}
+
+func TestAs2(t *testing.T) {
+	src := New(codes.NotFound, "UserNotFound", "ユーザーが存在しません。")
+	want := *ErrInternal
+
+	// 定義済みエラーを指すターゲットを渡しても, 定義済みエラーは変更しない
+	dst := ErrInternal
+	if !src.As(&dst) {
+		t.Errorf("Expected As to succeed")
+		return
+	}
+	if dst == ErrInternal || dst == src {
+		t.Errorf("\n  got: %p\n  want: new *Error", dst)
+		return
+	}
+	if dst.Code() != codes.NotFound || dst.Reason() != "UserNotFound" {
+		t.Errorf("\n  got: %s, %s\n  want: %s, %s", dst.Code(), dst.Reason(), codes.NotFound, "UserNotFound")
+		return
+	}
+	if ErrInternal.code != want.code || ErrInternal.reason != want.reason || ErrInternal.message != want.message {
+		t.Errorf("\n  got: %s, %s, %s\n  want: %s, %s, %s", ErrInternal.code, ErrInternal.reason, ErrInternal.message, want.code, want.reason, want.message)
+		return
+	}
+
+	// nil を指すターゲットでも panic しない
+	var got *Error
+	if !src.As(&got) || got.Reason() != "UserNotFound" {
+		t.Errorf("Expected As to set target")
+		return
+	}
+}
+
+func TestWithMessage1(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "ラップ先のメッセージ", err: W(ErrInternal), want: ErrInternal.message},
+		{name: "指定したメッセージ", err: W(ErrInternal, WithMessage("message")), want: "message"},
+		{name: "外部エラー", err: W(&testErrorPtr{}, WithMessage("message")), want: "message"},
+		{name: "多段ラップ", err: W(W(ErrInternal, WithMessage("inner")), WithMessage("outer")), want: "outer"},
+		{name: "外側のみラップ", err: W(W(ErrInternal, WithMessage("inner"))), want: "inner"},
+		// 空文字の場合はラップ先のメッセージにフォールバックする
+		{name: "空文字", err: W(ErrInternal, WithMessage("")), want: ErrInternal.message},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.err.(*Error).Message()
+			if got != test.want {
+				t.Errorf("\n  got: %s\n  want: %s", got, test.want)
+				return
+			}
+		})
+	}
+}
+
+func TestNewWrap4(t *testing.T) {
+	doSomething := func() error { return nil }
+
+	if err := W(doSomething()); err != nil {
+		t.Errorf("\n  got: %v\n  want: nil", err)
+		return
+	}
+	if err := W(W(nil, WithTrace("trace"))); err != nil {
+		t.Errorf("\n  got: %v\n  want: nil", err)
+		return
+	}
+
+	// 非 nil インターフェースで中身が nil のエラーは従来通りラップする
+	var errPtr *testErrorPtr
+	if err := W(errPtr); err == nil {
+		t.Errorf("\n  got: nil\n  want: non-nil")
+		return
+	}
+}
+
+func TestCode1(t *testing.T) {
+	tests := []struct {
+		err  *Error
+		want codes.Code
+	}{
+		{err: W(fmt.Errorf("wrap: %w", status.Error(codes.NotFound, ""))).(*Error), want: codes.NotFound},
+		{err: W(fmt.Errorf("wrap: %w", W(fmt.Errorf("wrap: %w", ErrUnavailable)))).(*Error), want: codes.Unavailable},
+		{err: W(fmt.Errorf("wrap: %w", fmt.Errorf("wrap: %w", &testErrorPtr{}))).(*Error), want: codes.Unknown},
+	}
+	for _, test := range tests {
+		got := test.err.Code()
+		if got != test.want {
+			t.Errorf("[%v] got: %s, want: %s", test.err, got, test.want)
+			return
+		}
+	}
+}
+
+func TestCode2(t *testing.T) {
+	// 自己ラップした場合も無限ループせずに打ち切られる
+	err := W(&testErrorPtr{}).(*Error)
+	err.error = err
+	if got := err.Code(); got != codes.Unknown {
+		t.Errorf("\n  got: %s\n  want: %s", got, codes.Unknown)
+		return
+	}
+
+	err = W(&testErrorPtr{}).(*Error)
+	err.error = fmt.Errorf("wrap: %w", err)
+	if got := err.Code(); got != codes.Unknown {
+		t.Errorf("\n  got: %s\n  want: %s", got, codes.Unknown)
+		return
+	}
+}
+
+func TestEqual1(t *testing.T) {
+	base := New(codes.NotFound, "UserNotFound", "message").WithDomain("example.com")
+
+	tests := []struct {
+		name string
+		a    *Error
+		b    *Error
+		want bool
+	}{
+		{name: "nil 同士", a: nil, b: nil, want: true},
+		{name: "片方 nil", a: base, b: nil, want: false},
+		{name: "もう片方 nil", a: nil, b: base, want: false},
+		{name: "同一", a: base, b: base, want: true},
+		{name: "trace 違い", a: base, b: base.WithTrace("trace").(*Error), want: true},
+		{name: "ラップ", a: base, b: W(base, WithTrace("trace")).(*Error), want: true},
+		{name: "code 違い", a: base, b: base.WithCode(codes.Internal), want: false},
+		{name: "reason 違い", a: base, b: New(codes.NotFound, "Other", "message").WithDomain("example.com"), want: false},
+		{name: "message 違い", a: base, b: New(codes.NotFound, "UserNotFound", "other").WithDomain("example.com"), want: false},
+		{name: "domain 違い", a: base, b: base.WithDomain("other.example.com"), want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Equal(test.a, test.b); got != test.want {
+				t.Errorf("\n  got: %t\n  want: %t", got, test.want)
+				return
+			}
+		})
+	}
+}
+
+func TestNewWrap5(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	timeout, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-timeout.Done()
+
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{err: W(context.Canceled), want: codes.Canceled},
+		{err: W(context.DeadlineExceeded), want: codes.DeadlineExceeded},
+		{err: W(ctx.Err()), want: codes.Canceled},
+		{err: W(timeout.Err()), want: codes.DeadlineExceeded},
+		{err: W(fmt.Errorf("wrap: %w", context.Canceled)), want: codes.Canceled},
+		{err: W(W(context.DeadlineExceeded)), want: codes.DeadlineExceeded},
+		// 明示的に指定したコードが優先される
+		{err: W(context.Canceled, WithCode(codes.Internal)), want: codes.Internal},
+		{err: W(W(context.Canceled, WithCode(codes.Internal))), want: codes.Internal},
+		{err: W(fmt.Errorf("wrap: %w", W(context.Canceled, WithCode(codes.Internal)))), want: codes.Internal},
+	}
+	for _, test := range tests {
+		got := test.err.(*Error).Code()
+		if got != test.want {
+			t.Errorf("[%v] got: %s, want: %s", test.err, got, test.want)
+			return
+		}
+	}
+
+	if !Is(W(context.Canceled), context.Canceled) {
+		t.Errorf("Expected to match %v", context.Canceled)
+		return
+	}
+}
+
+func TestError1(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: ErrInternal.WithTrace("trace"), want: "システム内部でエラーが発生しました。"},
+		{err: W(ErrInternal.WithTrace("trace"), WithTrace("wrap")), want: "システム内部でエラーが発生しました。"},
+		{err: W(&testErrorPtr{}, WithTrace("wrap")), want: "ptr"},
+	}
+	for _, test := range tests {
+		// trace や frame を含む書式化の結果には依存しない
+		got := test.err.Error()
+		if got != test.want {
+			t.Errorf("\n  got: %s\n  want: %s", got, test.want)
+			return
+		}
+		if detail := fmt.Sprintf("%+v", test.err); detail == got || !strings.Contains(detail, "trace") && !strings.Contains(detail, "wrap") {
+			t.Errorf("\n  got: %s\n  want: detail with trace", detail)
+			return
+		}
+	}
+}
+
+func TestIsCode1(t *testing.T) {
+	tests := []struct {
+		err     error
+		targets []codes.Code
+		want    bool
+	}{
+		{err: ErrNotFound, targets: []codes.Code{codes.NotFound}, want: true},
+		{err: ErrNotFound, targets: []codes.Code{codes.NotFound, codes.AlreadyExists}, want: true},
+		{err: ErrAlreadyExists, targets: []codes.Code{codes.NotFound, codes.AlreadyExists}, want: true},
+		{err: W(W(ErrAlreadyExists)), targets: []codes.Code{codes.NotFound, codes.AlreadyExists}, want: true},
+		{err: fmt.Errorf("wrap: %w", ErrNotFound), targets: []codes.Code{codes.NotFound}, want: true},
+		{err: status.Error(codes.NotFound, ""), targets: []codes.Code{codes.NotFound}, want: true},
+		{err: fmt.Errorf("wrap: %w", status.Error(codes.NotFound, "")), targets: []codes.Code{codes.NotFound}, want: true},
+		// GRPCStatus と Code() の両方を実装したエラーでは GRPCStatus を優先する
+		{err: fmt.Errorf("wrap: %w", &testCodeError{}), targets: []codes.Code{codes.Unavailable}, want: true},
+		{err: fmt.Errorf("wrap: %w", Join(ErrNotFound, ErrInternal)), targets: []codes.Code{codes.NotFound}, want: true},
+		{err: ErrInternal, targets: []codes.Code{codes.NotFound, codes.AlreadyExists}, want: false},
+		{err: ErrInternal, targets: nil, want: false},
+		{err: nil, targets: []codes.Code{codes.OK}, want: false},
+	}
+	for _, test := range tests {
+		got := IsCode(test.err, test.targets...)
+		if got != test.want {
+			t.Errorf("[%v] %v got: %t, want: %t", test.err, test.targets, got, test.want)
+			return
+		}
+	}
+}
+
+func TestFormatWithoutFrame1(t *testing.T) {
+	var unmarshaled Error
+	if err := unmarshaled.UnmarshalJSON([]byte(`{"code":"NotFound","reason":"NotFound","message":"not found"}`)); err != nil {
+		t.Errorf("unmarshal: %v", err)
+		return
+	}
+	tests := []struct {
+		err  *Error
+		want string
+	}{
+		{err: &Error{code: codes.NotFound, reason: "NotFound", message: "not found"}, want: "NotFound: not found"},
+		{err: &unmarshaled, want: "NotFound: not found"},
+		{err: &Error{message: "only message"}, want: "only message"},
+		{err: &Error{}, want: ""},
+	}
+	for _, test := range tests {
+		got := fmt.Sprintf("%+v", test.err)
+		if got != test.want {
+			t.Errorf("\n  got: %q\n  want: %q", got, test.want)
+			return
+		}
+	}
+}
+
+type testCycleError struct {
+	next error
+}
+
+func (e *testCycleError) Error() string { return "cycle" }
+
+func (e *testCycleError) Unwrap() error { return e.next }
+
+func TestDepth1(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{err: nil, want: 0},
+		{err: fmt.Errorf("error"), want: 1},
+		{err: ErrNotFound, want: 1},
+		{err: W(ErrNotFound), want: 2},
+		{err: W(W(ErrNotFound)), want: 3},
+		{err: fmt.Errorf("wrap: %w", W(fmt.Errorf("error"))), want: 3},
+	}
+	for _, test := range tests {
+		if got := Depth(test.err); got != test.want {
+			t.Errorf("\n  got: %d\n  want: %d", got, test.want)
+			return
+		}
+	}
+}
+
+func TestDepth2(t *testing.T) {
+	a := &testCycleError{}
+	b := &testCycleError{next: a}
+	a.next = b
+
+	if got, want := Depth(a), 2; got != want {
+		t.Errorf("\n  got: %d\n  want: %d", got, want)
+		return
+	}
+	if got, want := Depth(fmt.Errorf("wrap: %w", a)), 3; got != want {
+		t.Errorf("\n  got: %d\n  want: %d", got, want)
+		return
+	}
+}
+
+func TestMessage2(t *testing.T) {
+	tests := []struct {
+		err  *Error
+		want string
+	}{
+		// *Error → *Error → 外部エラー
+		{err: W(W(status.Error(codes.NotFound, "not found"))).(*Error), want: ErrNotFound.message},
+		{err: W(W(fmt.Errorf("error"))).(*Error), want: ""},
+		// *Error → 外部エラー → *Error
+		{err: W(fmt.Errorf("wrap: %w", ErrAlreadyExists)).(*Error), want: ErrAlreadyExists.message},
+		{err: W(fmt.Errorf("wrap: %w", W(W(ErrInternal)))).(*Error), want: ErrInternal.message},
+		// 中間層で指定したメッセージが優先される
+		{err: W(fmt.Errorf("wrap: %w", W(ErrInternal, WithMessage("middle")))).(*Error), want: "middle"},
+	}
+	for _, test := range tests {
+		if got := test.err.Message(); got != test.want {
+			t.Errorf("\n  got: %s\n  want: %s", got, test.want)
+			return
+		}
+	}
+}
+
+func TestWalk1(t *testing.T) {
+	err := W(Join(W(ErrNotFound), fmt.Errorf("wrap: %w", ErrInternal)), WithTrace("top"))
+
+	// 各層の code/reason を収集する
+	var got []string
+	Walk(err, func(err error) bool {
+		if v, ok := err.(*Error); ok && v.isSource() {
+			got = append(got, fmt.Sprintf("%s/%s", v.Code(), v.Reason()))
+		}
+		return true
+	})
+	want := []string{"NotFound/NotFound", "Internal/Internal"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("\n  got: %v\n  want: %v", got, want)
+		return
+	}
+}
+
+func TestWalk2(t *testing.T) {
+	err := W(W(W(ErrNotFound)))
+
+	// false を返した時点で打ち切る
+	count := 0
+	Walk(err, func(err error) bool {
+		count++
+		return count < 2
+	})
+	if count != 2 {
+		t.Errorf("\n  got: %d\n  want: %d", count, 2)
+		return
+	}
+
+	count = 0
+	Walk(nil, func(err error) bool {
+		count++
+		return true
+	})
+	if count != 0 {
+		t.Errorf("\n  got: %d\n  want: %d", count, 0)
+		return
+	}
+}
+
+func TestWalk3(t *testing.T) {
+	a := &testCycleError{}
+	b := &testCycleError{next: a}
+	a.next = b
+
+	count := 0
+	Walk(Join(a, b), func(err error) bool {
+		count++
+		return true
+	})
+	// join, a, b の順に一度ずつ辿る
+	if count != 3 {
+		t.Errorf("\n  got: %d\n  want: %d", count, 3)
+		return
+	}
+}
+
+func TestOverride1(t *testing.T) {
+	// DB ドライバなどが返す Internal をアプリとしては NotFound に見せる
+	driverErr := status.Error(codes.Internal, "no rows")
+	err := W(
+		W(driverErr),
+		WithCode(codes.NotFound),
+		WithReason("UserNotFound"),
+		WithMessage("ユーザーが存在しません。"),
+	).(*Error)
+
+	if got := err.Code(); got != codes.NotFound {
+		t.Errorf("\n  got: %s\n  want: %s", got, codes.NotFound)
+		return
+	}
+	if got := err.Reason(); got != "UserNotFound" {
+		t.Errorf("\n  got: %s\n  want: %s", got, "UserNotFound")
+		return
+	}
+	if got := err.Message(); got != "ユーザーが存在しません。" {
+		t.Errorf("\n  got: %s\n  want: %s", got, "ユーザーが存在しません。")
+		return
+	}
+	// Error の文字列はラップ先のまま
+	if got := err.Error(); got != driverErr.Error() {
+		t.Errorf("\n  got: %s\n  want: %s", got, driverErr.Error())
+		return
+	}
+
+	// 外側でさらにラップしてもオーバーライドした値を返す
+	outer := W(err).(*Error)
+	if outer.Code() != codes.NotFound || outer.Reason() != "UserNotFound" || outer.Message() != "ユーザーが存在しません。" {
+		t.Errorf("\n  got: %s/%s/%s\n  want: %s/%s/%s", outer.Code(), outer.Reason(), outer.Message(), codes.NotFound, "UserNotFound", "ユーザーが存在しません。")
+		return
+	}
+
+	// ラップ先との比較やラップの判定には影響しない
+	if !Is(err, driverErr) {
+		t.Errorf("Expected to match %v", driverErr)
+		return
+	}
+	if got := errorInfoOf(t, err.GRPCStatus()).GetReason(); got != "UserNotFound" {
+		t.Errorf("\n  got: %s\n  want: %s", got, "UserNotFound")
+		return
+	}
+}
+
+func TestIsStatus1(t *testing.T) {
+	withReason := func(code codes.Code, reason string) error {
+		s, _ := status.New(code, "").WithDetails(&errdetails.ErrorInfo{Reason: reason})
+		return s.Err()
+	}
+
+	tests := []struct {
+		err           error
+		target        error
+		matchesReason bool
+		want          bool
+	}{
+		{err: ErrNotFound, target: status.Error(codes.NotFound, ""), want: true},
+		{err: W(ErrNotFound), target: status.Error(codes.NotFound, ""), want: true},
+		{err: fmt.Errorf("wrap: %w", ErrNotFound), target: status.Error(codes.NotFound, ""), want: true},
+		{err: W(&testErrorPtr{}, WithCode(codes.NotFound)), target: status.Error(codes.NotFound, ""), want: true},
+		{err: ErrNotFound, target: status.Error(codes.Internal, ""), want: false},
+		{err: ErrNotFound, target: withReason(codes.NotFound, "UserNotFound"), want: true},
+		{err: ErrNotFound, target: withReason(codes.NotFound, "UserNotFound"), matchesReason: true, want: false},
+		{err: ErrNotFound, target: withReason(codes.NotFound, "NotFound"), matchesReason: true, want: true},
+		{err: ErrNotFound, target: status.Error(codes.NotFound, ""), matchesReason: true, want: false},
+		{err: fmt.Errorf("wrap: %w", W(ErrNotFound)), target: withReason(codes.NotFound, "NotFound"), matchesReason: true, want: true},
+		{err: fmt.Errorf("wrap: %w", W(ErrNotFound)), target: withReason(codes.NotFound, "UserNotFound"), matchesReason: true, want: false},
+		// gRPC status を *Error と比較する
+		{err: ErrNotFound.GRPCStatus().Err(), target: ErrNotFound, want: false},
+		{err: W(ErrNotFound.GRPCStatus().Err()), target: status.Error(codes.NotFound, ""), want: true},
+	}
+	for i, test := range tests {
+		got := Is(test.err, test.target)
+		if test.matchesReason {
+			got = IsWithOptions(test.err, test.target, MatchStatusReason())
+		}
+		if got != test.want {
+			t.Errorf("[%d]\n  got: %t\n  want: %t", i, got, test.want)
+			return
+		}
+	}
+}
+
+func TestNewWithOptions1(t *testing.T) {
+	err := NewWithOptions(codes.NotFound, "UserNotFound",
+		WithMessage("ユーザーが存在しません。"),
+		WithDomain("example.com"),
+		WithTrace("user_id: 1"),
+		WithMetadata(map[string]string{"key": "value"}),
+	)
+
+	if got := err.Code(); got != codes.NotFound {
+		t.Errorf("\n  got: %s\n  want: %s", got, codes.NotFound)
+		return
+	}
+	if got := err.Reason(); got != "UserNotFound" {
+		t.Errorf("\n  got: %s\n  want: %s", got, "UserNotFound")
+		return
+	}
+	if got := err.Message(); got != "ユーザーが存在しません。" {
+		t.Errorf("\n  got: %s\n  want: %s", got, "ユーザーが存在しません。")
+		return
+	}
+	if got := err.Domain(); got != "example.com" {
+		t.Errorf("\n  got: %s\n  want: %s", got, "example.com")
+		return
+	}
+	if got := err.trace.Text; got != "user_id: 1" {
+		t.Errorf("\n  got: %s\n  want: %s", got, "user_id: 1")
+		return
+	}
+	if got := err.Metadata()["key"]; got != "value" {
+		t.Errorf("\n  got: %s\n  want: %s", got, "value")
+		return
+	}
+
+	// New で生成した場合と同じく code と reason で比較できる
+	if !Is(W(err), New(codes.NotFound, "UserNotFound", "")) {
+		t.Errorf("Expected to match by code and reason")
+		return
+	}
+
+	// 呼び出し元のフレームを記録する
+	if got := fmt.Sprintf("%+v", err); !strings.Contains(got, "TestNewWithOptions1") {
+		t.Errorf("\n  got: %s\n  want: contains %s", got, "TestNewWithOptions1")
+		return
+	}
+}
+
+func TestRootCause1(t *testing.T) {
+	base := &testErrorPtr{}
+	tests := []struct {
+		err  error
+		want error
+	}{
+		{err: nil, want: nil},
+		{err: base, want: base},
+		{err: ErrNotFound, want: ErrNotFound},
+		{err: W(base), want: base},
+		{err: W(W(W(base))), want: base},
+		{err: W(fmt.Errorf("wrap: %w", W(base))), want: base},
+		// 複数のブランチがある場合は先頭のブランチを辿る
+		{err: W(Join(W(base), ErrInternal)), want: base},
+	}
+	for _, test := range tests {
+		if got := RootCause(test.err); got != test.want {
+			t.Errorf("\n  got: %v\n  want: %v", got, test.want)
+			return
+		}
+	}
+}
+
+func TestRootCause2(t *testing.T) {
+	a := &testCycleError{}
+	b := &testCycleError{next: a}
+	a.next = b
+
+	if got := RootCause(a); got != b {
+		t.Errorf("\n  got: %p\n  want: %p", got, b)
+		return
+	}
+}
+
+func TestFormatVerbs1(t *testing.T) {
+	err := W(ErrNotFound.WithTrace("user_id: 1"))
+	plain := W(&testErrorPtr{})
+
+	tests := []struct {
+		format string
+		err    error
+		want   string
+	}{
+		{format: "%s", err: err, want: ErrNotFound.message},
+		{format: "%q", err: err, want: `"` + ErrNotFound.message + `"`},
+		{format: "%v", err: err, want: ErrNotFound.message},
+		// message が空の場合は Error を使う
+		{format: "%s", err: plain, want: "ptr"},
+		{format: "%q", err: plain, want: `"ptr"`},
+		{format: "%q", err: New(codes.Internal, "", `say "hi"`), want: `"say \"hi\""`},
+	}
+	for _, test := range tests {
+		if got := fmt.Sprintf(test.format, test.err); got != test.want {
+			t.Errorf("[%s]\n  got: %s\n  want: %s", test.format, got, test.want)
+			return
+		}
+	}
+
+	// %+v は従来どおり trace と frame を出力する
+	got := fmt.Sprintf("%+v", err)
+	for _, want := range []string{"NotFound: " + ErrNotFound.message, "user_id: 1", "error_test.go"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("\n  got: %s\n  want: contains %s", got, want)
+			return
+		}
+	}
+}
+
+func BenchmarkNew(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = New(codes.NotFound, "NotFound", "not found")
+	}
+}
+
+func BenchmarkNewWrap(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = W(ErrNotFound)
+	}
+}
+
+func TestError2(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: New(codes.NotFound, "MyReason", "message"), want: "message"},
+		// message が空の場合は reason を使う
+		{err: New(codes.NotFound, "MyReason", ""), want: "MyReason"},
+		// reason も空の場合は code の名前を使う
+		{err: New(codes.NotFound, "", ""), want: "NotFound"},
+		{err: New(codes.OK, "", ""), want: "OK"},
+		{err: &Error{}, want: "OK"},
+		{err: W(New(codes.Internal, "", "")), want: "Internal"},
+		{err: W(New(codes.Internal, "", ""), WithMessage("wrapped")), want: "Internal"},
+	}
+	for _, test := range tests {
+		if got := test.err.Error(); got != test.want {
+			t.Errorf("\n  got: %s\n  want: %s", got, test.want)
+			return
+		}
+	}
+}
+
+func TestAsType1(t *testing.T) {
+	errPtr := &testErrorPtr{}
+	err := fmt.Errorf("wrap: %w", W(W(errPtr)))
+
+	if v, ok := AsType[*testErrorPtr](err); !ok || v != errPtr {
+		t.Errorf("\n  got: %v, %t\n  want: %v, true", v, ok, errPtr)
+		return
+	}
+	if v, ok := AsType[*Error](err); !ok || v.Code() != codes.Unknown {
+		t.Errorf("\n  got: %v, %t\n  want: *Error, true", v, ok)
+		return
+	}
+	if v, ok := AsType[interface {
+		error
+		GRPCStatus() *status.Status
+	}](err); !ok || v == nil {
+		t.Errorf("\n  got: %v, %t\n  want: GRPCStatus, true", v, ok)
+		return
+	}
+
+	// 型が一致しない場合はゼロ値を返す
+	if v, ok := AsType[testErrorVal](err); ok || v != (testErrorVal{}) {
+		t.Errorf("\n  got: %v, %t\n  want: zero, false", v, ok)
+		return
+	}
+	if v, ok := AsType[*Error](nil); ok || v != nil {
+		t.Errorf("\n  got: %v, %t\n  want: <nil>, false", v, ok)
+		return
+	}
+}
+
+func TestWithSnapshot1(t *testing.T) {
+	src := New(codes.NotFound, "UserNotFound", "ユーザーが存在しません。")
+	pinned := W(src, WithSnapshot()).(*Error)
+	overridden := W(src, WithSnapshot(), WithCode(codes.Internal)).(*Error)
+	plain := W(src).(*Error)
+
+	// 通常は不変だが, ラップ後にラップ先が変化した場合を再現する
+	src.code = codes.Unavailable
+	src.reason = "Changed"
+	src.message = "changed"
+
+	tests := []struct {
+		err             *Error
+		code            codes.Code
+		reason, message string
+	}{
+		{err: pinned, code: codes.NotFound, reason: "UserNotFound", message: "ユーザーが存在しません。"},
+		{err: overridden, code: codes.Internal, reason: "UserNotFound", message: "ユーザーが存在しません。"},
+		// 固定しない場合はラップ先を辿る
+		{err: plain, code: codes.Unavailable, reason: "Changed", message: "changed"},
+	}
+	for _, test := range tests {
+		if test.err.Code() != test.code || test.err.Reason() != test.reason || test.err.Message() != test.message {
+			t.Errorf("\n  got: %s, %s, %s\n  want: %s, %s, %s", test.err.Code(), test.err.Reason(), test.err.Message(), test.code, test.reason, test.message)
+			return
+		}
+	}
+	// ラップした層であることは変わらない
+	if !Is(pinned, src) {
+		t.Errorf("\n  got: %t\n  want: %t", false, true)
+		return
+	}
+}
+
+// testCodeError は, GRPCStatus と Code の両方を実装した外部のエラー.
+type testCodeError struct {
+	err error
+}
+
+func (e *testCodeError) Error() string              { return "code" }
+func (e *testCodeError) Unwrap() error              { return e.err }
+func (e *testCodeError) GRPCStatus() *status.Status { return status.New(codes.Unavailable, "") }
+func (e *testCodeError) Code() codes.Code           { return codes.Aborted }
+
+func TestCodePriority1(t *testing.T) {
+	tests := []struct {
+		err  *Error
+		want codes.Code
+	}{
+		// GRPCStatus を Code より優先する
+		{err: W(&testCodeError{}).(*Error), want: codes.Unavailable},
+		{err: W(fmt.Errorf("wrap: %w", &testCodeError{})).(*Error), want: codes.Unavailable},
+		// 内側に *Error の明示的なコードがあれば外部のエラーより優先する
+		{err: W(&testCodeError{err: ErrNotFound}).(*Error), want: codes.NotFound},
+		{err: W(&testCodeError{err: W(ErrNotFound, WithCode(codes.Internal))}).(*Error), want: codes.Internal},
+		// 外側の明示的なコードが最優先
+		{err: W(&testCodeError{err: ErrNotFound}, WithCode(codes.DataLoss)).(*Error), want: codes.DataLoss},
+		// 外部のエラーが複数ある場合は最も近いものを使う
+		{err: W(&testCodeError{err: status.Error(codes.NotFound, "")}).(*Error), want: codes.Unavailable},
+	}
+	for i, test := range tests {
+		if got := test.err.Code(); got != test.want {
+			t.Errorf("[%d]\n  got: %s\n  want: %s", i, got, test.want)
+			return
+		}
+	}
+}
+
+func TestCycle1(t *testing.T) {
+	// 意図せず a.error = b, b.error = a の循環が作られた場合を再現する
+	a := W(ErrNotFound, WithMetadata(map[string]string{"a": "1"})).(*Error)
+	b := W(a, WithLocale("x-cycle")).(*Error)
+	a.error = b
+
+	for _, err := range []*Error{a, b} {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = err.Error()
+			_ = err.Code()
+			_ = err.Message()
+			_ = err.Reason()
+			_ = err.Domain()
+			_ = err.Metadata()
+			_ = err.Locale()
+			_ = err.LocalizedMessage("x-cycle")
+			_ = err.GRPCStatus()
+			_ = fmt.Sprintf("%v %s", err, err)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Errorf("\n  got: timeout\n  want: done")
+			return
+		}
+	}
+
+	if got, want := a.Error(), "Unknown"; got != want {
+		t.Errorf("\n  got: %s\n  want: %s", got, want)
+		return
+	}
+	if got, want := a.Metadata()["a"], "1"; got != want {
+		t.Errorf("\n  got: %s\n  want: %s", got, want)
+		return
+	}
+}
+
+func TestNewAuto1(t *testing.T) {
+	tests := []struct {
+		code codes.Code
+		want *Error
+	}{
+		{code: codes.Canceled, want: ErrCanceled},
+		{code: codes.Unknown, want: ErrUnknown},
+		{code: codes.InvalidArgument, want: ErrInvalidArgument},
+		{code: codes.DeadlineExceeded, want: ErrDeadlineExceeded},
+		{code: codes.NotFound, want: ErrNotFound},
+		{code: codes.AlreadyExists, want: ErrAlreadyExists},
+		{code: codes.PermissionDenied, want: ErrPermissionDenied},
+		{code: codes.ResourceExhausted, want: ErrResourceExhausted},
+		{code: codes.FailedPrecondition, want: ErrFailedPrecondition},
+		{code: codes.Aborted, want: ErrAborted},
+		{code: codes.OutOfRange, want: ErrOutOfRange},
+		{code: codes.Unimplemented, want: ErrUnimplemented},
+		{code: codes.Internal, want: ErrInternal},
+		{code: codes.Unavailable, want: ErrUnavailable},
+		{code: codes.DataLoss, want: ErrDataLoss},
+		{code: codes.Unauthenticated, want: ErrUnauthenticated},
+	}
+	for _, test := range tests {
+		got := NewAuto(test.code, "Custom")
+		if got.Code() != test.code || got.Reason() != "Custom" || got.Message() != test.want.message || got.Message() == "" {
+			t.Errorf("\n  got: %s, %s, %s\n  want: %s, %s, %s", got.Code(), got.Reason(), got.Message(), test.code, "Custom", test.want.message)
+			return
+		}
+	}
+
+	// 定義済みエラーが無いコードは message を空にする
+	if got := NewAuto(codes.OK, "Custom").Message(); got != "" {
+		t.Errorf("\n  got: %s\n  want: %s", got, "")
+		return
+	}
+}
+
+func TestWithSampling1(t *testing.T) {
+	random := 0.0
+	randFloat64 = func() float64 { return random }
+	t.Cleanup(func() { randFloat64 = rand.Float64 })
+
+	tests := []struct {
+		rate   float64
+		random float64
+		want   bool
+	}{
+		{rate: 0.1, random: 0.05, want: true},
+		{rate: 0.1, random: 0.1, want: false},
+		{rate: 0.1, random: 0.5, want: false},
+		{rate: 1, random: 0.99, want: true},
+		{rate: 0, random: 0, want: false},
+	}
+	for _, test := range tests {
+		random = test.random
+		errs := []*Error{
+			New(codes.NotFound, "UserNotFound", "ユーザーが存在しません。", WithSampling(test.rate), WithTrace("user_id: 1")),
+			W(New(codes.NotFound, "UserNotFound", "ユーザーが存在しません。"), WithSampling(test.rate), WithTrace("user_id: 1")).(*Error),
+		}
+		for _, err := range errs {
+			recorded := err.trace != nil && err.frame != (xerrors.Frame{}) && len(err.stack) > 0
+			if recorded != test.want {
+				t.Errorf("[%v %v]\n  got: %t\n  want: %t", test.rate, test.random, recorded, test.want)
+				return
+			}
+			// サンプリングされなかった場合も code/reason/message は保持する
+			if err.Code() != codes.NotFound || err.Reason() != "UserNotFound" || err.Message() != "ユーザーが存在しません。" {
+				t.Errorf("\n  got: %s", err.Snapshot())
+				return
+			}
+			_ = fmt.Sprintf("%+v", err)
+		}
+	}
+}
+
+func TestNewWithOptions2(t *testing.T) {
+	tests := []struct {
+		err    *Error
+		code   codes.Code
+		reason string
+	}{
+		// ラップ先を上書きするオプションは生成時には無視する
+		{err: New(codes.NotFound, "UserNotFound", "", WithCode(codes.Internal)), code: codes.NotFound, reason: "UserNotFound"},
+		{err: NewWithOptions(codes.NotFound, "UserNotFound", WithReason("Other")), code: codes.NotFound, reason: "UserNotFound"},
+		{err: NewWithOptions(codes.NotFound, "UserNotFound", WithCode(codes.Internal), WithSnapshot()), code: codes.NotFound, reason: "UserNotFound"},
+		{err: New(codes.NotFound, "UserNotFound", "", WithSnapshot()), code: codes.NotFound, reason: "UserNotFound"},
+	}
+	for _, test := range tests {
+		if got := test.err.Code(); got != test.code {
+			t.Errorf("\n  got: %s\n  want: %s", got, test.code)
+			return
+		}
+		if got := test.err.Reason(); got != test.reason {
+			t.Errorf("\n  got: %s\n  want: %s", got, test.reason)
+			return
+		}
+		// Code と Is の結果が一致する
+		if !Is(test.err, New(test.code, test.reason, "")) {
+			t.Errorf("Expected to match %v", test.err)
+			return
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Package ersconnect は, ers パッケージのエラーを Connect RPC のエラーに変換するヘルパーを提供する.
+// Connect への依存を ers パッケージに持ち込まないよう, 別モジュールとして分離している.
+package ersconnect
+
+import (
+	"connectrpc.com/connect"
+	"github.com/tys-muta/go-ers"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+)
+
+// Codes は, gRPC のコードに対応する Connect のコード.
+// Connect のコードは gRPC のコードと同じ意味で定義されている.
+var Codes = map[codes.Code]connect.Code{
+	codes.Canceled:           connect.CodeCanceled,
+	codes.Unknown:            connect.CodeUnknown,
+	codes.InvalidArgument:    connect.CodeInvalidArgument,
+	codes.DeadlineExceeded:   connect.CodeDeadlineExceeded,
+	codes.NotFound:           connect.CodeNotFound,
+	codes.AlreadyExists:      connect.CodeAlreadyExists,
+	codes.PermissionDenied:   connect.CodePermissionDenied,
+	codes.ResourceExhausted:  connect.CodeResourceExhausted,
+	codes.FailedPrecondition: connect.CodeFailedPrecondition,
+	codes.Aborted:            connect.CodeAborted,
+	codes.OutOfRange:         connect.CodeOutOfRange,
+	codes.Unimplemented:      connect.CodeUnimplemented,
+	codes.Internal:           connect.CodeInternal,
+	codes.Unavailable:        connect.CodeUnavailable,
+	codes.DataLoss:           connect.CodeDataLoss,
+	codes.Unauthenticated:    connect.CodeUnauthenticated,
+}
+
+// ToConnectError は, err を Connect のエラーに変換する.
+// ラップ先まで辿って解決した code を Connect のコードにマップし,
+// reason と domain を errdetails.ErrorInfo として details に付与する.
+// Connect のメッセージには GRPCStatus と同じく表示用の Message を使い, ラップ先のエラー文字列は含めない.
+// Connect には OK に相当するコードが無いため, codes.OK や未知のコードは connect.CodeUnknown にする.
+// err が nil の場合は nil を返す.
+func ToConnectError(err error) *connect.Error {
+	if err == nil {
+		return nil
+	}
+
+	var e *ers.Error
+	if !ers.As(err, &e) {
+		e = ers.NewWrap(err).(*ers.Error)
+	}
+
+	code, ok := Codes[e.Code()]
+	if !ok {
+		code = connect.CodeUnknown
+	}
+	v := connect.NewError(code, &publicError{err: err, message: e.Message()})
+
+	info := &errdetails.ErrorInfo{
+		Reason: e.Reason(),
+		Domain: e.Domain(),
+	}
+	if detail, err := connect.NewErrorDetail(info); err == nil {
+		v.AddDetail(detail)
+	}
+	return v
+}
+
+// publicError は, Connect のメッセージとして表示用のメッセージを返すエラー.
+// Unwrap で元のエラーを辿れるようにする.
+type publicError struct {
+	err     error
+	message string
+}
+
+func (e *publicError) Error() string {
+	return e.message
+}
+
+func (e *publicError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,100 @@
+package ersconnect
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/tys-muta/go-ers"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+)
+
+func TestCodes1(t *testing.T) {
+	// Connect のコードは gRPC のコードと同じ値で定義されている
+	for code := codes.Canceled; code <= codes.Unauthenticated; code++ {
+		got, ok := Codes[code]
+		if !ok || uint32(got) != uint32(code) {
+			t.Errorf("[%s]\n  got: %s\n  want: %d", code, got, code)
+			return
+		}
+	}
+	if _, ok := Codes[codes.OK]; ok {
+		t.Errorf("Expected codes.OK not to be mapped")
+		return
+	}
+}
+
+func TestToConnectError1(t *testing.T) {
+	err := ToConnectError(ers.W(ers.ErrNotFound.WithDomain("example.com")))
+
+	if err.Code() != connect.CodeNotFound {
+		t.Errorf("\n  got: %s\n  want: %s", err.Code(), connect.CodeNotFound)
+		return
+	}
+
+	var info *errdetails.ErrorInfo
+	for _, detail := range err.Details() {
+		v, err := detail.Value()
+		if err != nil {
+			t.Errorf("\n  got: %v\n  want: <nil>", err)
+			return
+		}
+		if v, ok := v.(*errdetails.ErrorInfo); ok {
+			info = v
+		}
+	}
+	if info.GetReason() != "NotFound" || info.GetDomain() != "example.com" {
+		t.Errorf("\n  got: %s, %s\n  want: %s, %s", info.GetReason(), info.GetDomain(), "NotFound", "example.com")
+		return
+	}
+
+	// 元のエラーを辿れる
+	if !ers.Is(err, ers.ErrNotFound) {
+		t.Errorf("Expected to match %v", ers.ErrNotFound)
+		return
+	}
+}
+
+func TestToConnectError2(t *testing.T) {
+	if err := ToConnectError(nil); err != nil {
+		t.Errorf("\n  got: %v\n  want: <nil>", err)
+		return
+	}
+
+	tests := []struct {
+		err  error
+		want connect.Code
+	}{
+		{err: errors.New("error"), want: connect.CodeUnknown},
+		{err: ers.New(codes.OK, "OK", ""), want: connect.CodeUnknown},
+		{err: ers.W(ers.ErrUnavailable), want: connect.CodeUnavailable},
+	}
+	for _, test := range tests {
+		if got := ToConnectError(test.err).Code(); got != test.want {
+			t.Errorf("\n  got: %s\n  want: %s", got, test.want)
+			return
+		}
+	}
+}
+
+func TestToConnectError3(t *testing.T) {
+	cause := fmt.Errorf("users: %w", fmt.Errorf("dial tcp 10.0.0.1:5432: connection refused"))
+	err := ToConnectError(fmt.Errorf("handler: %w", ers.W(cause, ers.WithCode(codes.Unavailable), ers.WithMessage("一時的に利用できません。"))))
+
+	// ラップ先のエラー文字列はメッセージに含めない
+	if got, want := err.Message(), "一時的に利用できません。"; got != want {
+		t.Errorf("\n  got: %s\n  want: %s", got, want)
+		return
+	}
+	if err.Code() != connect.CodeUnavailable {
+		t.Errorf("\n  got: %s\n  want: %s", err.Code(), connect.CodeUnavailable)
+		return
+	}
+	// 元のエラーは辿れる
+	if !errors.Is(err, cause) {
+		t.Errorf("Expected to match %v", cause)
+		return
+	}
+}
@@ -0,0 +1,57 @@
+// Package ersgrpc は, gRPC のインターセプタで ers パッケージのエラーを扱うためのヘルパーを提供する.
+package ersgrpc
+
+import (
+	"context"
+
+	"github.com/tys-muta/go-ers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor は, ハンドラが返したエラーを *ers.Error に正規化するインターセプタを返す.
+// *ers.Error 以外のエラーは ers.NewWrap で包み, GRPCStatus によって code や reason がクライアントに伝わるようにする.
+// ハンドラが panic した場合は recover して codes.Internal のエラーに変換する.
+// panic の値はサーバ側のログ用にラップ先に保持し, クライアントには ers.ErrInternal のメッセージのみを返す.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				resp = nil
+				// ers.Recover の message や trace には panic の値が含まれるため, 公開用のメッセージでラップする
+				err = ers.NewWrap(ers.Recover(r), ers.WithMessage(ers.ErrInternal.Message()))
+			}
+		}()
+
+		resp, err = handler(ctx, req)
+		return resp, normalize(err)
+	}
+}
+
+// normalize は, err を GRPCStatus を実装した *ers.Error に変換する.
+// gRPC はラップ先を辿らずに GRPCStatus を探すため, 最外層が *ers.Error でない場合は包み直す.
+func normalize(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*ers.Error); ok {
+		return err
+	}
+	return ers.NewWrap(err)
+}
+
+// UnaryClientInterceptor は, サーバから返された status を *ers.Error に変換するインターセプタを返す.
+// 変換後のエラーは ers.Is や ers.As で code や reason を検証でき, GRPCStatus で元の status と同じ内容を取り出せる.
+// status でないエラー (接続エラーなど) は ers.NewWrap で包む.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil {
+			return nil
+		}
+		if s, ok := status.FromError(err); ok {
+			return ers.FromGRPCStatus(s)
+		}
+		return ers.NewWrap(err)
+	}
+}
@@ -0,0 +1,174 @@
+package ersgrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tys-muta/go-ers"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func invokeServer(handler grpc.UnaryHandler) (interface{}, error) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
+	return UnaryServerInterceptor()(context.Background(), "req", info, handler)
+}
+
+func reasonOf(s *status.Status) string {
+	for _, detail := range s.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+func TestUnaryServerInterceptor1(t *testing.T) {
+	tests := []struct {
+		err    error
+		code   codes.Code
+		reason string
+	}{
+		{err: ers.ErrNotFound.WithTrace("user_id: 1"), code: codes.NotFound, reason: "NotFound"},
+		{err: ers.W(ers.ErrAlreadyExists), code: codes.AlreadyExists, reason: "AlreadyExists"},
+		{err: fmt.Errorf("wrap: %w", ers.ErrAborted), code: codes.Aborted, reason: "Aborted"},
+		{err: status.Error(codes.Unavailable, "unavailable"), code: codes.Unavailable, reason: ""},
+		{err: errors.New("error"), code: codes.Unknown, reason: ""},
+	}
+	for _, test := range tests {
+		_, err := invokeServer(func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, test.err
+		})
+
+		// gRPC サーバと同じく, 最外層の GRPCStatus からステータスを取得する
+		if _, ok := err.(interface{ GRPCStatus() *status.Status }); !ok {
+			t.Errorf("Expected %T to implement GRPCStatus", err)
+			return
+		}
+		s, _ := status.FromError(err)
+		if s.Code() != test.code {
+			t.Errorf("\n  got: %s\n  want: %s", s.Code(), test.code)
+			return
+		}
+		if got := reasonOf(s); got != test.reason {
+			t.Errorf("\n  got: %s\n  want: %s", got, test.reason)
+			return
+		}
+	}
+}
+
+func TestUnaryServerInterceptor2(t *testing.T) {
+	resp, err := invokeServer(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "resp", nil
+	})
+	if err != nil || resp != "resp" {
+		t.Errorf("\n  got: %v, %v\n  want: resp, <nil>", resp, err)
+		return
+	}
+}
+
+func TestUnaryServerInterceptor3(t *testing.T) {
+	resp, err := invokeServer(func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	})
+	if resp != nil {
+		t.Errorf("\n  got: %v\n  want: <nil>", resp)
+		return
+	}
+	if !ers.Is(err, ers.ErrInternal) {
+		t.Errorf("\n  got: %v\n  want: %v", err, ers.ErrInternal)
+		return
+	}
+	s, _ := status.FromError(err)
+	if s.Code() != codes.Internal {
+		t.Errorf("\n  got: %s\n  want: %s", s.Code(), codes.Internal)
+		return
+	}
+
+	// panic の値はクライアントに返さない
+	if s.Message() != ers.ErrInternal.Message() {
+		t.Errorf("\n  got: %s\n  want: %s", s.Message(), ers.ErrInternal.Message())
+		return
+	}
+	for _, detail := range s.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			for k, v := range info.GetMetadata() {
+				if strings.Contains(v, "boom") {
+					t.Errorf("\n  got: %s=%s\n  want: no panic value", k, v)
+					return
+				}
+			}
+		}
+	}
+	// サーバ側ではラップ先から panic の値を参照できる
+	if !strings.Contains(fmt.Sprintf("%+v", err), "boom") {
+		t.Errorf("\n  got: %+v\n  want: contains boom", err)
+		return
+	}
+}
+
+func invokeClient(err error) error {
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return err
+	}
+	return UnaryClientInterceptor()(context.Background(), "/test.Service/Method", "req", nil, nil, invoker)
+}
+
+func TestUnaryClientInterceptor1(t *testing.T) {
+	// サーバから返された status を再現する
+	src := ers.ErrNotFound.WithTrace("user_id: 1").(*ers.Error).GRPCStatus()
+
+	err := invokeClient(src.Err())
+	if !ers.Is(err, ers.ErrNotFound) {
+		t.Errorf("\n  got: %v\n  want: %v", err, ers.ErrNotFound)
+		return
+	}
+	var v *ers.Error
+	if !ers.As(err, &v) {
+		t.Errorf("Expected As to find *ers.Error in %T", err)
+		return
+	}
+
+	// 元の status と同じ内容を取り出せる
+	got := v.GRPCStatus()
+	if got.Code() != src.Code() {
+		t.Errorf("\n  got: %s\n  want: %s", got.Code(), src.Code())
+		return
+	}
+	if got.Message() != src.Message() {
+		t.Errorf("\n  got: %s\n  want: %s", got.Message(), src.Message())
+		return
+	}
+	if reasonOf(got) != reasonOf(src) {
+		t.Errorf("\n  got: %s\n  want: %s", reasonOf(got), reasonOf(src))
+		return
+	}
+}
+
+func TestUnaryClientInterceptor2(t *testing.T) {
+	if err := invokeClient(nil); err != nil {
+		t.Errorf("\n  got: %v\n  want: <nil>", err)
+		return
+	}
+
+	// status でないエラーは包んで返す
+	src := errors.New("connection refused")
+	err := invokeClient(src)
+	if _, ok := err.(*ers.Error); !ok {
+		t.Errorf("\n  got: %T\n  want: *ers.Error", err)
+		return
+	}
+	if !errors.Is(err, src) {
+		t.Errorf("Expected to match %v", src)
+		return
+	}
+	if got := err.(*ers.Error).Code(); got != codes.Unknown {
+		t.Errorf("\n  got: %s\n  want: %s", got, codes.Unknown)
+		return
+	}
+}
@@ -0,0 +1,48 @@
+// Package erstest は, ers パッケージのエラーを検証するテスト用ヘルパーを提供する.
+package erstest
+
+import (
+	"testing"
+
+	"github.com/tys-muta/go-ers"
+	"google.golang.org/grpc/codes"
+)
+
+// AssertCode は, err のコードが want であることを検証する.
+// ラップされている場合もラップ先を辿って検証する.
+func AssertCode(t testing.TB, err error, want codes.Code) {
+	t.Helper()
+
+	v, ok := asError(t, err)
+	if !ok {
+		return
+	}
+	if v.Code() != want {
+		t.Errorf("unexpected code\n  got: %s\n  want: %s\n  reason: %s\n  message: %s", v.Code(), want, v.Reason(), v.Message())
+	}
+}
+
+// AssertReason は, err の reason が want であることを検証する.
+// ラップされている場合もラップ先を辿って検証する.
+func AssertReason(t testing.TB, err error, want string) {
+	t.Helper()
+
+	v, ok := asError(t, err)
+	if !ok {
+		return
+	}
+	if v.Reason() != want {
+		t.Errorf("unexpected reason\n  got: %s\n  want: %s\n  code: %s\n  message: %s", v.Reason(), want, v.Code(), v.Message())
+	}
+}
+
+func asError(t testing.TB, err error) (*ers.Error, bool) {
+	t.Helper()
+
+	var v *ers.Error
+	if !ers.As(err, &v) {
+		t.Errorf("error is not *ers.Error\n  got: %v", err)
+		return nil, false
+	}
+	return v, true
+}
@@ -0,0 +1,73 @@
+package erstest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tys-muta/go-ers"
+	"google.golang.org/grpc/codes"
+)
+
+type testTB struct {
+	testing.TB
+	failed  bool
+	message string
+}
+
+func (t *testTB) Helper() {}
+
+func (t *testTB) Errorf(format string, args ...any) {
+	t.failed = true
+	t.message = fmt.Sprintf(format, args...)
+}
+
+func TestAssertCode1(t *testing.T) {
+	tests := []struct {
+		err      error
+		want     codes.Code
+		failed   bool
+		contains []string
+	}{
+		{err: ers.ErrNotFound, want: codes.NotFound},
+		{err: ers.W(ers.W(ers.ErrNotFound)), want: codes.NotFound},
+		{err: fmt.Errorf("wrap: %w", ers.ErrNotFound), want: codes.NotFound},
+		{err: ers.ErrNotFound, want: codes.Internal, failed: true, contains: []string{"NotFound", "Internal", "存在しないデータへの参照が発生しています。"}},
+		{err: fmt.Errorf("plain"), want: codes.Unknown, failed: true, contains: []string{"plain"}},
+	}
+	for _, test := range tests {
+		tb := &testTB{}
+		AssertCode(tb, test.err, test.want)
+		if tb.failed != test.failed {
+			t.Errorf("[%v] got: %t, want: %t", test.err, tb.failed, test.failed)
+			return
+		}
+		for _, want := range test.contains {
+			if !strings.Contains(tb.message, want) {
+				t.Errorf("\n  got: %s\n  want: contains %s", tb.message, want)
+				return
+			}
+		}
+	}
+}
+
+func TestAssertReason1(t *testing.T) {
+	tests := []struct {
+		err    error
+		want   string
+		failed bool
+	}{
+		{err: ers.ErrNotFound, want: "NotFound"},
+		{err: ers.W(ers.ErrNotFound), want: "NotFound"},
+		{err: ers.ErrNotFound, want: "Internal", failed: true},
+		{err: nil, want: "", failed: true},
+	}
+	for _, test := range tests {
+		tb := &testTB{}
+		AssertReason(tb, test.err, test.want)
+		if tb.failed != test.failed {
+			t.Errorf("[%v] got: %t, want: %t", test.err, tb.failed, test.failed)
+			return
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package erstest
+
+import (
+	"github.com/tys-muta/go-ers"
+	"google.golang.org/grpc/codes"
+)
+
+// CodeMatcher は, err のコードが code である場合に true を返す関数を返す.
+// ラップされている場合もラップ先を辿って判定する. *ers.Error を含まない場合は false を返す.
+//
+//	assert.True(t, erstest.CodeMatcher(codes.NotFound)(err))
+//	assert.Condition(t, func() bool { return erstest.CodeMatcher(codes.NotFound)(err) })
+func CodeMatcher(code codes.Code) func(error) bool {
+	return matcher(func(v *ers.Error) bool {
+		return v.Code() == code
+	})
+}
+
+// ReasonMatcher は, err の reason が reason である場合に true を返す関数を返す.
+// ラップされている場合もラップ先を辿って判定する. *ers.Error を含まない場合は false を返す.
+func ReasonMatcher(reason string) func(error) bool {
+	return matcher(func(v *ers.Error) bool {
+		return v.Reason() == reason
+	})
+}
+
+// DomainMatcher は, err の domain が domain である場合に true を返す関数を返す.
+// ラップされている場合もラップ先を辿って判定する. *ers.Error を含まない場合は false を返す.
+func DomainMatcher(domain string) func(error) bool {
+	return matcher(func(v *ers.Error) bool {
+		return v.Domain() == domain
+	})
+}
+
+// MessageMatcher は, err の表示用メッセージが message である場合に true を返す関数を返す.
+// ラップされている場合もラップ先を辿って判定する. *ers.Error を含まない場合は false を返す.
+func MessageMatcher(message string) func(error) bool {
+	return matcher(func(v *ers.Error) bool {
+		return v.Message() == message
+	})
+}
+
+// matcher は, err に含まれる最も外側の *ers.Error を fn で判定する関数を返す.
+func matcher(fn func(v *ers.Error) bool) func(error) bool {
+	return func(err error) bool {
+		var v *ers.Error
+		if !ers.As(err, &v) {
+			return false
+		}
+		return fn(v)
+	}
+}
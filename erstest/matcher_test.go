@@ -0,0 +1,37 @@
+package erstest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tys-muta/go-ers"
+	"google.golang.org/grpc/codes"
+)
+
+func TestMatcher1(t *testing.T) {
+	err := fmt.Errorf("wrap: %w", ers.W(ers.New(codes.NotFound, "UserNotFound", "ユーザーが存在しません。").WithDomain("example.com")))
+
+	tests := []struct {
+		match func(error) bool
+		err   error
+		want  bool
+	}{
+		{match: CodeMatcher(codes.NotFound), err: err, want: true},
+		{match: CodeMatcher(codes.Internal), err: err, want: false},
+		{match: ReasonMatcher("UserNotFound"), err: err, want: true},
+		{match: ReasonMatcher("NotFound"), err: err, want: false},
+		{match: DomainMatcher("example.com"), err: err, want: true},
+		{match: DomainMatcher("other.com"), err: err, want: false},
+		{match: MessageMatcher("ユーザーが存在しません。"), err: err, want: true},
+		{match: MessageMatcher(""), err: err, want: false},
+		// *ers.Error を含まない場合は一致しない
+		{match: CodeMatcher(codes.Unknown), err: fmt.Errorf("plain"), want: false},
+		{match: ReasonMatcher(""), err: nil, want: false},
+	}
+	for i, test := range tests {
+		if got := test.match(test.err); got != test.want {
+			t.Errorf("[%d]\n  got: %t\n  want: %t", i, got, test.want)
+			return
+		}
+	}
+}
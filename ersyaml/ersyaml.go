@@ -0,0 +1,91 @@
+// Package ersyaml は, ers パッケージのエラーを YAML で入出力するヘルパーを提供する.
+// YAML ライブラリへの依存を ers パッケージに持ち込まないよう, 別モジュールとして分離している.
+package ersyaml
+
+import (
+	"fmt"
+
+	"github.com/tys-muta/go-ers"
+	"gopkg.in/yaml.v3"
+)
+
+type Option func(o *options)
+
+type options struct {
+	trace bool
+}
+
+// WithTrace は, ラップチェーン全体の trace.Text を "traces" として出力する.
+// trace は機密情報を含む可能性があるため, 既定では出力しない.
+func WithTrace() Option {
+	return func(o *options) {
+		o.trace = true
+	}
+}
+
+// document は, YAML に出力するエラーの形式.
+type document struct {
+	Code    string   `yaml:"code"`
+	Reason  string   `yaml:"reason"`
+	Message string   `yaml:"message"`
+	Domain  string   `yaml:"domain"`
+	Traces  []string `yaml:"traces,omitempty"`
+}
+
+// Marshal は, err の code/reason/message/domain を YAML にする.
+// WithTrace を指定した場合は trace.Text をルート (最も内側) から順に含める.
+// err が *ers.Error を含まない場合はラップしてから出力する. err が nil の場合は null を出力する.
+func Marshal(err error, opts ...Option) ([]byte, error) {
+	if err == nil {
+		return yaml.Marshal(nil)
+	}
+
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var e *ers.Error
+	if !ers.As(err, &e) {
+		e = ers.NewWrap(err).(*ers.Error)
+	}
+
+	v := document{
+		Code:    e.Code().String(),
+		Reason:  e.Reason(),
+		Message: e.Message(),
+		Domain:  e.Domain(),
+	}
+	if o.trace {
+		for _, trace := range e.Traces() {
+			if trace.Text != "" {
+				v.Traces = append(v.Traces, trace.Text)
+			}
+		}
+	}
+	return yaml.Marshal(v)
+}
+
+// Unmarshal は, Marshal で出力した YAML からエラーを復元する.
+// traces が含まれる場合は, 先頭の trace を持つエラーを残りの trace で順にラップして復元する.
+func Unmarshal(data []byte) (*ers.Error, error) {
+	v := document{}
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+
+	code, ok := ers.CodeFromString(v.Code)
+	if !ok {
+		return nil, fmt.Errorf("ersyaml: unknown code %q", v.Code)
+	}
+
+	options := []ers.WrapOption{ers.WithMessage(v.Message), ers.WithDomain(v.Domain)}
+	if len(v.Traces) > 0 {
+		options = append(options, ers.WithTrace(v.Traces[0]))
+	}
+	e := ers.NewWithOptions(code, v.Reason, options...)
+	for i := 1; i < len(v.Traces); i++ {
+		e = ers.NewWrap(e, ers.WithTrace(v.Traces[i])).(*ers.Error)
+	}
+	return e, nil
+}
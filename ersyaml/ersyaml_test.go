@@ -0,0 +1,80 @@
+package ersyaml
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tys-muta/go-ers"
+	"google.golang.org/grpc/codes"
+)
+
+func TestMarshal1(t *testing.T) {
+	src := ers.W(ers.W(ers.New(codes.NotFound, "UserNotFound", "ユーザーが存在しません。").WithDomain("example.com"), ers.WithTrace("user_id: 1")), ers.WithTrace("handler"))
+
+	tests := []struct {
+		options    []Option
+		wantTraces []string
+	}{
+		// 既定では trace を出力しない
+		{options: nil, wantTraces: nil},
+		{options: []Option{WithTrace()}, wantTraces: []string{"user_id: 1", "handler"}},
+	}
+	for _, test := range tests {
+		data, err := Marshal(src, test.options...)
+		if err != nil {
+			t.Errorf("Failed to marshal: %s", err)
+			return
+		}
+		if got := strings.Contains(string(data), "user_id: 1"); got != (test.wantTraces != nil) {
+			t.Errorf("\n  got: %s\n  want: traces %v", data, test.wantTraces)
+			return
+		}
+
+		got, err := Unmarshal(data)
+		if err != nil {
+			t.Errorf("Failed to unmarshal: %s", err)
+			return
+		}
+		if got.Code() != codes.NotFound || got.Reason() != "UserNotFound" || got.Message() != "ユーザーが存在しません。" || got.Domain() != "example.com" {
+			t.Errorf("\n  got: %s", got.Snapshot())
+			return
+		}
+		traces := got.Traces()
+		if len(traces) != len(test.wantTraces) {
+			t.Errorf("\n  got: %d\n  want: %d", len(traces), len(test.wantTraces))
+			return
+		}
+		for i, trace := range traces {
+			if trace.Text != test.wantTraces[i] {
+				t.Errorf("\n  got: %s\n  want: %s", trace.Text, test.wantTraces[i])
+				return
+			}
+		}
+	}
+}
+
+func TestMarshal2(t *testing.T) {
+	// *ers.Error を含まないエラーはラップしてから出力する
+	data, err := Marshal(errors.New("plain"))
+	if err != nil {
+		t.Errorf("Failed to marshal: %s", err)
+		return
+	}
+	got, err := Unmarshal(data)
+	if err != nil {
+		t.Errorf("Failed to unmarshal: %s", err)
+		return
+	}
+	if got.Code() != codes.Unknown {
+		t.Errorf("\n  got: %s\n  want: %s", got.Code(), codes.Unknown)
+		return
+	}
+}
+
+func TestUnmarshal1(t *testing.T) {
+	if _, err := Unmarshal([]byte("code: Unexpected\n")); err == nil {
+		t.Errorf("\n  got: nil\n  want: error")
+		return
+	}
+}
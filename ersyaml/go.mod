@@ -0,0 +1,18 @@
+module github.com/tys-muta/go-ers/ersyaml
+
+go 1.21
+
+require (
+	github.com/tys-muta/go-ers v0.0.0-00010101000000-000000000000
+	google.golang.org/grpc v1.47.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/golang/protobuf v1.5.2 // indirect
+	golang.org/x/xerrors v0.0.0-20220609144429-65e65417b02f // indirect
+	google.golang.org/genproto v0.0.0-20200526211855-cb27e3aa2013 // indirect
+	google.golang.org/protobuf v1.27.1 // indirect
+)
+
+replace github.com/tys-muta/go-ers => ../
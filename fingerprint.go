@@ -0,0 +1,49 @@
+package ers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"runtime"
+	"strings"
+)
+
+// Fingerprint は, 同種のエラーをまとめるためのグルーピングキーを返す.
+// code/reason/domain と, NewR や Catalog で定義した番兵エラーを除いて最も内側の *Error を生成した関数名とファイル名から sha256 のハッシュを生成する.
+// 行番号や trace は含めないため, 同じ関数で生成したエラーは ID などの値が異なっても同じ値になる.
+// W(ErrNotFound) のように番兵エラーをラップした場合は, ラップした箇所で判定する.
+func (e *Error) Fingerprint() string {
+	parts := []string{e.Code().String(), e.Reason(), e.Domain()}
+
+	var pc uintptr
+	Walk(e, func(err error) bool {
+		if v, ok := err.(*Error); ok {
+			if origin := v.origin(); origin != 0 && !v.isSentinel() {
+				pc = origin
+			}
+		}
+		return true
+	})
+	if pc != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		parts = append(parts, frame.Function, frame.File)
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// origin は, エラーを生成した呼び出し元のプログラムカウンタを返す.
+func (e *Error) origin() uintptr {
+	if e.pc != 0 {
+		return e.pc
+	}
+	if len(e.stack) > 0 {
+		return e.stack[0]
+	}
+	return 0
+}
+
+// isSentinel は, NewR や Catalog で定義した番兵エラーかどうかを返す.
+func (e *Error) isSentinel() bool {
+	return e.sentinel
+}
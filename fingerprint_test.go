@@ -0,0 +1,115 @@
+package ers
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+var errFingerprintTest = NewR(codes.NotFound, "UserNotFound", "")
+
+// パッケージ変数のクロージャは, いずれも pkg.init.funcN という名前の関数になる
+var (
+	fingerprintTestHandlerA = func() error { return New(codes.NotFound, "UserNotFound", "") }
+	fingerprintTestHandlerB = func() error { return New(codes.NotFound, "UserNotFound", "") }
+)
+
+func fingerprintTestA(id int) error {
+	return W(New(codes.NotFound, "UserNotFound", ""), WithTrace(Tf("user_id: %d", id)))
+}
+
+func fingerprintTestB(id int) error {
+	return W(New(codes.NotFound, "UserNotFound", ""), WithTrace(Tf("user_id: %d", id)))
+}
+
+func TestFingerprint1(t *testing.T) {
+	a1 := fingerprintTestA(1).(*Error).Fingerprint()
+	a2 := fingerprintTestA(2).(*Error).Fingerprint()
+	b1 := fingerprintTestB(1).(*Error).Fingerprint()
+
+	// 同じ発生箇所であれば trace の値が異なっても同じになる
+	if a1 != a2 {
+		t.Errorf("\n  got: %s\n  want: %s", a2, a1)
+		return
+	}
+	// 発生箇所が異なれば異なる
+	if a1 == b1 {
+		t.Errorf("Expected different fingerprints: %s", a1)
+		return
+	}
+
+	// 外側でラップしても最も内側の発生箇所で判定する
+	if got := W(fingerprintTestA(3)).(*Error).Fingerprint(); got != a1 {
+		t.Errorf("\n  got: %s\n  want: %s", got, a1)
+		return
+	}
+	if len(a1) != 64 {
+		t.Errorf("\n  got: %d\n  want: %d", len(a1), 64)
+		return
+	}
+}
+
+func TestFingerprint2(t *testing.T) {
+	base := func(code codes.Code, reason, domain string) string {
+		return New(code, reason, "").WithDomain(domain).Fingerprint()
+	}
+	want := base(codes.NotFound, "UserNotFound", "example.com")
+
+	// code/reason/domain のいずれかが異なれば異なる
+	for _, got := range []string{
+		base(codes.Internal, "UserNotFound", "example.com"),
+		base(codes.NotFound, "Other", "example.com"),
+		base(codes.NotFound, "UserNotFound", "other.example.com"),
+	} {
+		if got == want {
+			t.Errorf("Expected different fingerprints: %s", got)
+			return
+		}
+	}
+}
+
+func fingerprintTestC(err error, rate float64) error {
+	return W(err, WithSampling(rate))
+}
+
+func fingerprintTestD(err error) error {
+	return W(err)
+}
+
+func TestFingerprint3(t *testing.T) {
+	for _, sentinel := range []error{ErrNotFound, errFingerprintTest, NewCatalog("users").Define(codes.NotFound, "UserNotFound", "")} {
+		c := fingerprintTestC(sentinel, 1).(*Error).Fingerprint()
+		d := fingerprintTestD(sentinel).(*Error).Fingerprint()
+
+		// 同じ番兵エラーでもラップした箇所が異なれば異なる
+		if c == d {
+			t.Errorf("Expected different fingerprints: %s", c)
+			return
+		}
+		// サンプリングしなかった場合も同じになる
+		if got := fingerprintTestC(sentinel, 0).(*Error).Fingerprint(); got != c {
+			t.Errorf("\n  got: %s\n  want: %s", got, c)
+			return
+		}
+		// 外側でラップしても番兵エラーをラップした箇所で判定する
+		if got := W(fingerprintTestD(sentinel)).(*Error).Fingerprint(); got != d {
+			t.Errorf("\n  got: %s\n  want: %s", got, d)
+			return
+		}
+	}
+}
+
+func TestFingerprint4(t *testing.T) {
+	a := fingerprintTestHandlerA().(*Error).Fingerprint()
+	b := fingerprintTestHandlerB().(*Error).Fingerprint()
+
+	// パッケージ変数のクロージャで生成したエラーも番兵エラーとして扱わない
+	if a == b {
+		t.Errorf("Expected different fingerprints: %s", a)
+		return
+	}
+	if got := fingerprintTestHandlerA().(*Error).Fingerprint(); got != a {
+		t.Errorf("\n  got: %s\n  want: %s", got, a)
+		return
+	}
+}
@@ -0,0 +1,167 @@
+package ers
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/xerrors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// metadataKeyTrace は, errdetails.ErrorInfo の Metadata に trace を格納するキー.
+const metadataKeyTrace = "Trace"
+
+// truncatedSuffix は, 切り詰めた文字列の末尾に付与する文字列.
+const truncatedSuffix = "...(truncated)"
+
+// MaxTraceMetadataBytes は, GRPCStatus で Metadata に格納する trace の最大バイト数.
+// gRPC のメッセージサイズ制限を超えないよう, 超えた分は切り詰める.
+// 0 以下の場合は制限しない.
+var MaxTraceMetadataBytes = 4096
+
+// IncludeTraceInStatus は, GRPCStatus で Metadata に trace を格納するかどうか.
+// 機密情報やサイズの観点から trace をクライアントに返したくない場合は false にする.
+var IncludeTraceInStatus = true
+
+// ToProto は, GRPCStatus と同じ内容の google.rpc.Status を返す.
+// gRPC を介さず proto メッセージとして扱いたい場合に使う.
+func (e *Error) ToProto() *spb.Status {
+	return e.GRPCStatus().Proto()
+}
+
+// FromGRPCStatus は, GRPCStatus で生成された status.Status からエラーを復元する.
+// details に errdetails.ErrorInfo が含まれない場合は code と message のみで構築する.
+func FromGRPCStatus(s *status.Status) *Error {
+	if s == nil {
+		return nil
+	}
+
+	e := &Error{
+		code:      s.Code(),
+		message:   s.Message(),
+		frame:     xerrors.Caller(1),
+		stack:     callers(1),
+		createdAt: nowFunc(),
+		trace:     NewTrace(""),
+	}
+	for _, detail := range s.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			e.reason = info.GetReason()
+			e.domain = info.GetDomain()
+			for k, v := range info.GetMetadata() {
+				if k == metadataKeyTrace {
+					e.trace = NewTrace(v)
+					continue
+				}
+				if e.metadata == nil {
+					e.metadata = map[string]string{}
+				}
+				e.metadata[k] = v
+			}
+		}
+		if v, ok := detail.(*errdetails.BadRequest); ok {
+			e.fieldViolations = append(e.fieldViolations, v.GetFieldViolations()...)
+		}
+		if v, ok := detail.(*errdetails.QuotaFailure); ok {
+			e.quotaViolations = append(e.quotaViolations, v.GetViolations()...)
+		}
+		if v, ok := detail.(*errdetails.RetryInfo); ok {
+			d := v.GetRetryDelay().AsDuration()
+			e.retryDelay = &d
+		}
+	}
+	return notify(e)
+}
+
+// FieldViolations は, ラップチェーン全体で WithFieldViolation により指定された入力値の違反を外側の層から順に返す.
+// FromGRPCStatus で復元したエラーでは, details の errdetails.BadRequest の内容を返す.
+func (e *Error) FieldViolations() []*errdetails.BadRequest_FieldViolation {
+	var violations []*errdetails.BadRequest_FieldViolation
+	Walk(e, func(err error) bool {
+		if v, ok := err.(*Error); ok {
+			violations = append(violations, v.fieldViolations...)
+		}
+		return true
+	})
+	return violations
+}
+
+// QuotaViolations は, ラップチェーン全体で WithQuotaFailure により指定されたクォータの違反を外側の層から順に返す.
+// コードが codes.ResourceExhausted 以外の場合は, 指定されていても nil を返す.
+func (e *Error) QuotaViolations() []*errdetails.QuotaFailure_Violation {
+	if e.Code() != codes.ResourceExhausted {
+		return nil
+	}
+	var violations []*errdetails.QuotaFailure_Violation
+	Walk(e, func(err error) bool {
+		if v, ok := err.(*Error); ok {
+			violations = append(violations, v.quotaViolations...)
+		}
+		return true
+	})
+	return violations
+}
+
+// RetryDelay は, err の status の details に含まれる errdetails.RetryInfo の再試行の間隔を返す.
+// ラップチェーン中で最も外側の GRPCStatus を実装したエラーの status を参照する.
+// RetryInfo が含まれない場合は 0 と false を返す.
+func RetryDelay(err error) (time.Duration, bool) {
+	var s *status.Status
+	Walk(err, func(err error) bool {
+		if v, ok := err.(interface{ GRPCStatus() *status.Status }); ok {
+			s = v.GRPCStatus()
+			return false
+		}
+		return true
+	})
+	for _, detail := range s.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			return info.GetRetryDelay().AsDuration(), true
+		}
+	}
+	return 0, false
+}
+
+// retryDelayOf は, err のラップチェーン中で WithRetryDelay により指定された間隔のうち最も外側のものを返す.
+func retryDelayOf(err error) (time.Duration, bool) {
+	var d *time.Duration
+	Walk(err, func(err error) bool {
+		if v, ok := err.(*Error); ok && v.retryDelay != nil {
+			d = v.retryDelay
+			return false
+		}
+		return true
+	})
+	if d == nil {
+		return 0, false
+	}
+	return *d, true
+}
+
+// helpLinks は, err のラップチェーン全体で WithHelp により指定されたリンクを外側の層から順に返す.
+func helpLinks(err error) []*errdetails.Help_Link {
+	var links []*errdetails.Help_Link
+	Walk(err, func(err error) bool {
+		if v, ok := err.(*Error); ok {
+			links = append(links, v.help...)
+		}
+		return true
+	})
+	return links
+}
+
+// truncate は, s が max バイトを超える場合に rune の境界で切り詰めて truncatedSuffix を付与する.
+// max が 0 以下の場合は切り詰めない.
+func truncate(s string, max int) string {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	n := max
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + truncatedSuffix
+}
@@ -0,0 +1,368 @@
+package ers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFromGRPCStatus1(t *testing.T) {
+	src := W(New(codes.NotFound, "UserNotFound", "ユーザーが存在しません。").WithDomain("example.com"), WithTrace("user_id: 1"))
+
+	// クライアント側では status.Convert で受け取った status から復元する
+	got := FromGRPCStatus(status.Convert(src))
+
+	if got.Code() != codes.NotFound {
+		t.Errorf("\n  got: %s\n  want: %s", got.Code(), codes.NotFound)
+		return
+	}
+	if got.Reason() != "UserNotFound" {
+		t.Errorf("\n  got: %s\n  want: %s", got.Reason(), "UserNotFound")
+		return
+	}
+	if got.Message() != "ユーザーが存在しません。" {
+		t.Errorf("\n  got: %s\n  want: %s", got.Message(), "ユーザーが存在しません。")
+		return
+	}
+	if got.Domain() != "example.com" {
+		t.Errorf("\n  got: %s\n  want: %s", got.Domain(), "example.com")
+		return
+	}
+	if got.trace.Text != "user_id: 1" {
+		t.Errorf("\n  got: %s\n  want: %s", got.trace.Text, "user_id: 1")
+		return
+	}
+	if !Is(got, New(codes.NotFound, "UserNotFound", "")) {
+		t.Errorf("Expected to match by code and reason")
+		return
+	}
+}
+
+func TestFromGRPCStatus2(t *testing.T) {
+	// ErrorInfo を含まない status は code と message のみで構築する
+	got := FromGRPCStatus(status.New(codes.Unavailable, "unavailable"))
+
+	if got.Code() != codes.Unavailable {
+		t.Errorf("\n  got: %s\n  want: %s", got.Code(), codes.Unavailable)
+		return
+	}
+	if got.Message() != "unavailable" {
+		t.Errorf("\n  got: %s\n  want: %s", got.Message(), "unavailable")
+		return
+	}
+	if got.Reason() != "" {
+		t.Errorf("\n  got: %s\n  want: %s", got.Reason(), "")
+		return
+	}
+	if FromGRPCStatus(nil) != nil {
+		t.Errorf("Expected nil for nil status")
+		return
+	}
+}
+
+func TestGRPCStatusMetadata1(t *testing.T) {
+	inner := W(ErrNotFound, WithMetadata(map[string]string{"request_id": "inner", "user_id": "1"}))
+	err := W(inner, WithTrace("trace"), WithMetadata(map[string]string{"request_id": "outer", "Trace": "user"})).(*Error)
+
+	// 外側のメタデータが優先される
+	want := map[string]string{"request_id": "outer", "user_id": "1", "Trace": "user"}
+	got := err.Metadata()
+	if len(got) != len(want) {
+		t.Errorf("\n  got: %v\n  want: %v", got, want)
+		return
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s\n  got: %s\n  want: %s", k, got[k], v)
+			return
+		}
+	}
+
+	// "Trace" キーは予約されており, trace の内容が優先される
+	info := errorInfoOf(t, err.GRPCStatus())
+	want["Trace"] = "trace"
+	for k, v := range want {
+		if info.Metadata[k] != v {
+			t.Errorf("%s\n  got: %s\n  want: %s", k, info.Metadata[k], v)
+			return
+		}
+	}
+
+	restored := FromGRPCStatus(err.GRPCStatus())
+	if restored.Metadata()["request_id"] != "outer" {
+		t.Errorf("\n  got: %v\n  want: request_id=outer", restored.Metadata())
+		return
+	}
+}
+
+func TestGRPCStatusMetadata2(t *testing.T) {
+	// trace が無い場合もユーザー指定の "Trace" キーは出力しない
+	err := W(ErrNotFound, WithMetadata(map[string]string{"Trace": "user"})).(*Error)
+
+	info := errorInfoOf(t, err.GRPCStatus())
+	if _, ok := info.Metadata["Trace"]; ok {
+		t.Errorf("\n  got: %v\n  want: no Trace key", info.Metadata)
+		return
+	}
+	if ErrNotFound.Metadata() != nil {
+		t.Errorf("\n  got: %v\n  want: nil", ErrNotFound.Metadata())
+		return
+	}
+}
+
+func errorInfoOf(t *testing.T, s *status.Status) *errdetails.ErrorInfo {
+	t.Helper()
+	for _, detail := range s.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info
+		}
+	}
+	t.Fatalf("ErrorInfo not found in %v", s.Details())
+	return nil
+}
+
+func TestGRPCStatus1(t *testing.T) {
+	// details を付与できない codes.OK でも status を返す
+	s := New(codes.OK, "OK", "").GRPCStatus()
+	if s == nil {
+		t.Errorf("\n  got: nil\n  want: status")
+		return
+	}
+	if s.Code() != codes.OK {
+		t.Errorf("\n  got: %s\n  want: %s", s.Code(), codes.OK)
+		return
+	}
+	if len(s.Details()) != 0 {
+		t.Errorf("\n  got: %v\n  want: no details", s.Details())
+		return
+	}
+}
+
+func TestGRPCStatus2(t *testing.T) {
+	backup := MaxTraceMetadataBytes
+	defer func() { MaxTraceMetadataBytes = backup }()
+
+	MaxTraceMetadataBytes = 10
+	err := ErrInternal.WithTrace("あいうえおかきくけこ").(*Error)
+
+	info := errorInfoOf(t, err.GRPCStatus())
+	if got, want := info.Metadata["Trace"], "あいう...(truncated)"; got != want {
+		t.Errorf("\n  got: %s\n  want: %s", got, want)
+		return
+	}
+
+	MaxTraceMetadataBytes = 0
+	info = errorInfoOf(t, err.GRPCStatus())
+	if got, want := info.Metadata["Trace"], "あいうえおかきくけこ"; got != want {
+		t.Errorf("\n  got: %s\n  want: %s", got, want)
+		return
+	}
+}
+
+func TestTruncate1(t *testing.T) {
+	tests := []struct {
+		src  string
+		max  int
+		want string
+	}{
+		{src: "abc", max: 0, want: "abc"},
+		{src: "abc", max: 3, want: "abc"},
+		{src: "abcd", max: 3, want: "abc...(truncated)"},
+		{src: "あいう", max: 4, want: "あ...(truncated)"},
+		{src: "あいう", max: 2, want: "...(truncated)"},
+	}
+	for _, test := range tests {
+		if got := truncate(test.src, test.max); got != test.want {
+			t.Errorf("\n  got: %s\n  want: %s", got, test.want)
+			return
+		}
+	}
+}
+
+func TestIncludeTraceInStatus1(t *testing.T) {
+	defer func(v bool) { IncludeTraceInStatus = v }(IncludeTraceInStatus)
+	IncludeTraceInStatus = false
+
+	err := W(ErrNotFound.WithDomain("example.com").WithTrace("user_id: 1"), WithMetadata(map[string]string{"key": "value"})).(*Error)
+	info := errorInfoOf(t, err.GRPCStatus())
+
+	if _, ok := info.GetMetadata()[metadataKeyTrace]; ok {
+		t.Errorf("\n  got: %v\n  want: no %s", info.GetMetadata(), metadataKeyTrace)
+		return
+	}
+	// 他のフィールドは維持する
+	if info.GetReason() != "NotFound" || info.GetDomain() != "example.com" || info.GetMetadata()["key"] != "value" {
+		t.Errorf("\n  got: %s, %s, %v\n  want: %s, %s, %v", info.GetReason(), info.GetDomain(), info.GetMetadata(), "NotFound", "example.com", map[string]string{"key": "value"})
+		return
+	}
+}
+
+func TestGRPCStatusWrapped1(t *testing.T) {
+	src := New(codes.NotFound, "UserNotFound", "ユーザーが存在しません。").WithDomain("example.com")
+
+	// GRPCStatus は各フィールドをアクセサ経由で解決する
+	for _, err := range []*Error{src, W(src).(*Error), W(W(W(src))).(*Error)} {
+		s := err.GRPCStatus()
+		info := errorInfoOf(t, s)
+		if s.Code() != codes.NotFound || s.Message() != "ユーザーが存在しません。" {
+			t.Errorf("\n  got: %s, %s\n  want: %s, %s", s.Code(), s.Message(), codes.NotFound, "ユーザーが存在しません。")
+			return
+		}
+		if info.GetReason() != "UserNotFound" || info.GetDomain() != "example.com" {
+			t.Errorf("\n  got: %s, %s\n  want: %s, %s", info.GetReason(), info.GetDomain(), "UserNotFound", "example.com")
+			return
+		}
+	}
+}
+
+func TestToProto1(t *testing.T) {
+	err := W(New(codes.NotFound, "UserNotFound", "ユーザーが存在しません。").WithDomain("example.com")).(*Error)
+
+	got := err.ToProto()
+	if codes.Code(got.GetCode()) != codes.NotFound || got.GetMessage() != "ユーザーが存在しません。" {
+		t.Errorf("\n  got: %d, %s\n  want: %d, %s", got.GetCode(), got.GetMessage(), codes.NotFound, "ユーザーが存在しません。")
+		return
+	}
+	if len(got.GetDetails()) != 1 {
+		t.Errorf("\n  got: %d\n  want: %d", len(got.GetDetails()), 1)
+		return
+	}
+	info := errorInfoOf(t, status.FromProto(got))
+	if info.GetReason() != "UserNotFound" || info.GetDomain() != "example.com" {
+		t.Errorf("\n  got: %s, %s\n  want: %s, %s", info.GetReason(), info.GetDomain(), "UserNotFound", "example.com")
+		return
+	}
+}
+
+func TestWithHelp1(t *testing.T) {
+	src := New(codes.FailedPrecondition, "AccountLocked", "", WithHelp("https://example.com/docs/locked", "ロック解除の手順"))
+	err := W(src, WithHelp("https://example.com/support", "サポート窓口"), WithHelp("https://example.com/faq", "FAQ")).(*Error)
+
+	var got *errdetails.Help
+	for _, detail := range err.GRPCStatus().Details() {
+		if v, ok := detail.(*errdetails.Help); ok {
+			got = v
+		}
+	}
+	want := []*errdetails.Help_Link{
+		{Url: "https://example.com/support", Description: "サポート窓口"},
+		{Url: "https://example.com/faq", Description: "FAQ"},
+		{Url: "https://example.com/docs/locked", Description: "ロック解除の手順"},
+	}
+	if len(got.GetLinks()) != len(want) {
+		t.Errorf("\n  got: %d\n  want: %d", len(got.GetLinks()), len(want))
+		return
+	}
+	for i, link := range got.GetLinks() {
+		if link.GetUrl() != want[i].GetUrl() || link.GetDescription() != want[i].GetDescription() {
+			t.Errorf("\n  got: %s %s\n  want: %s %s", link.GetUrl(), link.GetDescription(), want[i].GetUrl(), want[i].GetDescription())
+			return
+		}
+	}
+
+	// リンクが無い場合は Help を付与しない
+	for _, detail := range W(ErrNotFound).(*Error).GRPCStatus().Details() {
+		if _, ok := detail.(*errdetails.Help); ok {
+			t.Errorf("\n  got: %v\n  want: no Help", detail)
+			return
+		}
+	}
+}
+
+func TestFieldViolations1(t *testing.T) {
+	src := W(ErrInvalidArgument, WithFieldViolation("name", "必須です。"), WithFieldViolation("age", "0 以上を指定してください。"))
+
+	// クライアント側で復元しても同じ違反を取り出せる
+	got := FromGRPCStatus(status.Convert(src)).FieldViolations()
+	want := []*errdetails.BadRequest_FieldViolation{
+		{Field: "name", Description: "必須です。"},
+		{Field: "age", Description: "0 以上を指定してください。"},
+	}
+	if len(got) != len(want) {
+		t.Errorf("\n  got: %d\n  want: %d", len(got), len(want))
+		return
+	}
+	for i := range got {
+		if got[i].GetField() != want[i].GetField() || got[i].GetDescription() != want[i].GetDescription() {
+			t.Errorf("\n  got: %s %s\n  want: %s %s", got[i].GetField(), got[i].GetDescription(), want[i].GetField(), want[i].GetDescription())
+			return
+		}
+	}
+
+	if got := W(ErrInvalidArgument).(*Error).FieldViolations(); got != nil {
+		t.Errorf("\n  got: %v\n  want: nil", got)
+		return
+	}
+}
+
+func TestQuotaViolations1(t *testing.T) {
+	tests := []struct {
+		err  error
+		want []*errdetails.QuotaFailure_Violation
+	}{
+		{
+			err:  W(ErrResourceExhausted, WithQuotaFailure("user:1", "1 分あたりのリクエスト数を超えました。")),
+			want: []*errdetails.QuotaFailure_Violation{{Subject: "user:1", Description: "1 分あたりのリクエスト数を超えました。"}},
+		},
+		{
+			err:  W(W(ErrUnavailable, WithQuotaFailure("user:1", "")), WithCode(codes.ResourceExhausted)),
+			want: []*errdetails.QuotaFailure_Violation{{Subject: "user:1"}},
+		},
+		// codes.ResourceExhausted 以外では無視する
+		{
+			err:  W(ErrUnavailable, WithQuotaFailure("user:1", "")),
+			want: nil,
+		},
+	}
+	for _, test := range tests {
+		// クライアント側で復元しても同じ違反を取り出せる
+		got := FromGRPCStatus(status.Convert(test.err)).QuotaViolations()
+		if len(got) != len(test.want) {
+			t.Errorf("\n  got: %d\n  want: %d", len(got), len(test.want))
+			return
+		}
+		for i := range got {
+			if got[i].GetSubject() != test.want[i].GetSubject() || got[i].GetDescription() != test.want[i].GetDescription() {
+				t.Errorf("\n  got: %s %s\n  want: %s %s", got[i].GetSubject(), got[i].GetDescription(), test.want[i].GetSubject(), test.want[i].GetDescription())
+				return
+			}
+		}
+	}
+}
+
+func TestRetryDelay1(t *testing.T) {
+	tests := []struct {
+		err    error
+		want   time.Duration
+		wantOK bool
+	}{
+		{err: W(ErrUnavailable, WithRetryDelay(3*time.Second)), want: 3 * time.Second, wantOK: true},
+		// 外側の層の指定が優先される
+		{err: W(W(ErrUnavailable, WithRetryDelay(time.Second)), WithRetryDelay(1500*time.Millisecond)), want: 1500 * time.Millisecond, wantOK: true},
+		// 0 は即時に再試行してよいことを表す
+		{err: W(ErrUnavailable, WithRetryDelay(0)), want: 0, wantOK: true},
+		{err: W(ErrUnavailable), want: 0, wantOK: false},
+		{err: fmt.Errorf("wrap: %w", W(ErrUnavailable, WithRetryDelay(time.Second))), want: time.Second, wantOK: true},
+		{err: errors.New("plain"), want: 0, wantOK: false},
+		{err: nil, want: 0, wantOK: false},
+	}
+	for _, test := range tests {
+		got, ok := RetryDelay(test.err)
+		if got != test.want || ok != test.wantOK {
+			t.Errorf("\n  got: %s, %t\n  want: %s, %t", got, ok, test.want, test.wantOK)
+			return
+		}
+	}
+
+	// クライアント側で復元しても同じ間隔を取り出せる
+	got, ok := RetryDelay(FromGRPCStatus(status.Convert(W(ErrUnavailable, WithRetryDelay(2*time.Second)))))
+	if got != 2*time.Second || !ok {
+		t.Errorf("\n  got: %s, %t\n  want: %s, %t", got, ok, 2*time.Second, true)
+		return
+	}
+}
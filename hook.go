@@ -0,0 +1,19 @@
+package ers
+
+// OnError は, エラー生成時に呼び出されるフック.
+// code/reason 別の発生件数をメトリクスとして集計する用途を想定している.
+//
+// New, NewR, NewWrap, WithTrace, Recover, Catalog の Define, FromGRPCStatus, ParseHTTPResponse
+// および非推奨の (*Error).New で *Error を生成した直後に, 生成したエラーを引数として同期的に呼び出す.
+// Format や Error の呼び出し時には呼び出さない.
+// nil の場合は何もしない.
+// 並行に呼び出されるため, フック内の処理は並行安全である必要がある.
+// 差し替えはエラーを生成する goroutine を起動する前 (初期化時) に行うこと.
+var OnError func(e *Error)
+
+func notify(e *Error) *Error {
+	if OnError != nil {
+		OnError(e)
+	}
+	return e
+}
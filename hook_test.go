@@ -0,0 +1,101 @@
+package ers
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestOnError1(t *testing.T) {
+	var got []codes.Code
+	OnError = func(e *Error) {
+		got = append(got, e.Code())
+	}
+	defer func() { OnError = nil }()
+
+	_ = New(codes.NotFound, "NotFound", "")
+	_ = NewR(codes.AlreadyExists, ReasonAlreadyExists, "")
+	_ = ErrInternal.WithTrace("trace")
+	_ = W(errors.New("error"), WithCode(codes.Unavailable))
+	_ = W(nil)
+
+	want := []codes.Code{codes.NotFound, codes.AlreadyExists, codes.Internal, codes.Unavailable}
+	if len(got) != len(want) {
+		t.Errorf("\n  got: %v\n  want: %v", got, want)
+		return
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("\n  got: %v\n  want: %v", got, want)
+			return
+		}
+	}
+}
+
+func TestOnError2(t *testing.T) {
+	// 生成時のみ呼び出し, 書式化では呼び出さない
+	var count int
+	OnError = func(e *Error) {
+		count++
+	}
+	defer func() { OnError = nil }()
+
+	err := W(ErrNotFound)
+	_ = err.Error()
+	_ = IsCode(err, codes.NotFound)
+	if count != 1 {
+		t.Errorf("\n  got: %d\n  want: %d", count, 1)
+		return
+	}
+}
+
+func TestOnError3(t *testing.T) {
+	var count int64
+	OnError = func(e *Error) {
+		atomic.AddInt64(&count, 1)
+	}
+	defer func() { OnError = nil }()
+
+	const n = 100
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = W(ErrNotFound.WithTrace("trace"))
+		}()
+	}
+	wg.Wait()
+	if got := atomic.LoadInt64(&count); got != 2*n {
+		t.Errorf("\n  got: %d\n  want: %d", got, 2*n)
+		return
+	}
+}
+
+func TestOnError4(t *testing.T) {
+	var got []string
+	OnError = func(e *Error) {
+		got = append(got, e.Reason())
+	}
+	defer func() { OnError = nil }()
+
+	_ = ErrNotFound.New("trace")
+	_ = NewCatalog("users").Define(codes.NotFound, "UserNotFound", "")
+	_ = FromGRPCStatus(ErrAborted.GRPCStatus())
+	_ = Recover("boom")
+
+	want := []string{"NotFound", "UserNotFound", "Aborted", "Internal"}
+	if len(got) != len(want) {
+		t.Errorf("\n  got: %v\n  want: %v", got, want)
+		return
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("\n  got: %v\n  want: %v", got, want)
+			return
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package ers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	HeaderErrorCode   = "X-Error-Code"
+	HeaderErrorReason = "X-Error-Reason"
+	HeaderErrorDomain = "X-Error-Domain"
+)
+
+// statusClientClosedRequest は, nginx 由来のクライアント切断を表すステータスコード.
+const statusClientClosedRequest = 499
+
+// WriteHTTPOption は, WriteHTTP の挙動を変更するオプション.
+type WriteHTTPOption func(o *writeHTTPOptions)
+
+type writeHTTPOptions struct {
+	OKOnNil bool
+}
+
+// WithOKOnNil は, nil エラーの場合に 200 を書き込むオプション.
+// 指定しない場合, nil エラーでは何も書き込まない.
+func WithOKOnNil() WriteHTTPOption {
+	return func(o *writeHTTPOptions) {
+		o.OKOnNil = true
+	}
+}
+
+// WriteHTTP は, err に対応するステータスコードと JSON ボディを w に書き込む.
+// *Error 以外のエラーはラップして書き込む.
+func WriteHTTP(w http.ResponseWriter, err error, options ...WriteHTTPOption) {
+	o := writeHTTPOptions{}
+	for _, option := range options {
+		option(&o)
+	}
+
+	if err == nil {
+		if o.OKOnNil {
+			w.WriteHeader(http.StatusOK)
+		}
+		return
+	}
+
+	var v *Error
+	if !As(err, &v) {
+		v = NewWrap(err).(*Error)
+	}
+	body, err := v.MarshalJSON()
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(v.HTTPStatus())
+	_, _ = w.Write(body)
+}
+
+// HTTPStatus は, ラップ先まで辿って解決したコードに対応する HTTP ステータスコードを返す.
+func (e *Error) HTTPStatus() int {
+	return HTTPStatusFromCode(e.Code())
+}
+
+// HTTPStatusFromCode は, codes.Code に対応する HTTP ステータスコードを返す.
+// 未知のコードの場合は 500 を返す.
+func HTTPStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return statusClientClosedRequest
+	case codes.Unknown:
+		return http.StatusInternalServerError
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.Aborted:
+		return http.StatusConflict
+	case codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Internal:
+		return http.StatusInternalServerError
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DataLoss:
+		return http.StatusInternalServerError
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	}
+	return http.StatusInternalServerError
+}
+
+// WriteHeader は, code/reason/domain を h に設定する.
+// 値が空のヘッダーは設定しない.
+// ヘッダーインジェクションを防ぐため, 改行などの制御文字は取り除く.
+func (e *Error) WriteHeader(h http.Header) {
+	values := []struct {
+		key   string
+		value string
+	}{
+		{key: HeaderErrorCode, value: e.Code().String()},
+		{key: HeaderErrorReason, value: e.Reason()},
+		{key: HeaderErrorDomain, value: e.Domain()},
+	}
+	for _, v := range values {
+		if value := sanitizeHeaderValue(v.value); value != "" {
+			h.Set(v.key, value)
+		}
+	}
+}
+
+// sanitizeHeaderValue は, ヘッダーの値として不正な制御文字を取り除く.
+func sanitizeHeaderValue(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r == '\t' || (r >= 0x20 && r != 0x7f) {
+			return r
+		}
+		return -1
+	}, s)
+	return textproto.TrimString(s)
+}
+
+// CodeFromHTTPStatus は, HTTP ステータスコードに対応する codes.Code を返す.
+// 複数のコードが対応するステータスコードは代表的なコードを返す.
+func CodeFromHTTPStatus(status int) codes.Code {
+	switch status {
+	case http.StatusOK:
+		return codes.OK
+	case statusClientClosedRequest:
+		return codes.Canceled
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusInternalServerError:
+		return codes.Internal
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	}
+	return codes.Unknown
+}
+
+// ParseHTTPResponse は, HTTP レスポンスからエラーを復元する.
+// 2xx の場合は nil を返す. resp が nil の場合は, レスポンスを受け取れていないため ErrInternal を返す.
+// ボディが MarshalJSON の形式の場合は code/reason/message/domain を読み取り,
+// そうでない場合はステータスコードから逆引きした code とボディ文字列を message としたエラーを返す.
+// ボディが空の場合は http.StatusText を message とする.
+// ボディは読み取るが Close はしない.
+func ParseHTTPResponse(resp *http.Response) error {
+	if resp == nil {
+		return ErrInternal.WithTrace("ParseHTTPResponse: resp is nil")
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	e := &Error{
+		code:      CodeFromHTTPStatus(resp.StatusCode),
+		message:   http.StatusText(resp.StatusCode),
+		frame:     xerrors.Caller(1),
+		stack:     callers(1),
+		createdAt: nowFunc(),
+	}
+	if resp.Body == nil {
+		return notify(e)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return NewWrap(err)
+	}
+
+	// code を持たない JSON は MarshalJSON の形式ではないため, ボディ文字列として扱う
+	v := jsonError{}
+	if err := json.Unmarshal(body, &v); err != nil || v.Code == "" {
+		if text := strings.TrimSpace(string(body)); text != "" {
+			e.message = text
+		}
+		return notify(e)
+	}
+	// ステータスコードより詳細なため, ボディの code を優先する
+	if code, ok := CodeFromString(v.Code); ok {
+		e.code = code
+	}
+	e.reason = v.Reason
+	e.message = v.Message
+	e.domain = v.Domain
+	return notify(e)
+}
@@ -0,0 +1,317 @@
+package ers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHTTPStatusFromCode1(t *testing.T) {
+	tests := []struct {
+		code codes.Code
+		want int
+	}{
+		{code: codes.OK, want: 200},
+		{code: codes.Canceled, want: 499},
+		{code: codes.Unknown, want: 500},
+		{code: codes.InvalidArgument, want: 400},
+		{code: codes.DeadlineExceeded, want: 504},
+		{code: codes.NotFound, want: 404},
+		{code: codes.AlreadyExists, want: 409},
+		{code: codes.PermissionDenied, want: 403},
+		{code: codes.ResourceExhausted, want: 429},
+		{code: codes.FailedPrecondition, want: 400},
+		{code: codes.Aborted, want: 409},
+		{code: codes.OutOfRange, want: 400},
+		{code: codes.Unimplemented, want: 501},
+		{code: codes.Internal, want: 500},
+		{code: codes.Unavailable, want: 503},
+		{code: codes.DataLoss, want: 500},
+		{code: codes.Unauthenticated, want: 401},
+		{code: codes.Code(100), want: 500},
+	}
+	for _, test := range tests {
+		t.Run(test.code.String(), func(t *testing.T) {
+			got := HTTPStatusFromCode(test.code)
+			if got != test.want {
+				t.Errorf("\n  got: %d\n  want: %d", got, test.want)
+				return
+			}
+		})
+	}
+}
+
+func TestHTTPStatus1(t *testing.T) {
+	tests := []struct {
+		err  *Error
+		want int
+	}{
+		{err: ErrNotFound, want: 404},
+		{err: W(ErrNotFound).(*Error), want: 404},
+		{err: W(W(ErrUnauthenticated)).(*Error), want: 401},
+		{err: W(status.Error(codes.Unavailable, "")).(*Error), want: 503},
+		{err: W(&testErrorPtr{}).(*Error), want: 500},
+	}
+	for _, test := range tests {
+		got := test.err.HTTPStatus()
+		if got != test.want {
+			t.Errorf("[%v] got: %d, want: %d", test.err, got, test.want)
+			return
+		}
+	}
+}
+
+func TestWriteHTTP1(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		body   string
+	}{
+		{
+			err:    ErrNotFound.WithTrace("secret"),
+			status: 404,
+			body:   `{"code":"NotFound","reason":"NotFound","message":"存在しないデータへの参照が発生しています。","domain":""}`,
+		},
+		{
+			err:    fmt.Errorf("wrap: %w", ErrUnauthenticated),
+			status: 401,
+			body:   `{"code":"Unauthenticated","reason":"Unauthenticated","message":"認証できませんでした。","domain":""}`,
+		},
+		{
+			// ラップされた標準エラーは codes.Unknown として 500 になる
+			err:    W(errors.New("plain")),
+			status: 500,
+			body:   `{"code":"Unknown","reason":"","message":"","domain":""}`,
+		},
+		{
+			err:    errors.New("plain"),
+			status: 500,
+			body:   `{"code":"Unknown","reason":"","message":"","domain":""}`,
+		},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		WriteHTTP(rec, test.err)
+
+		if rec.Code != test.status {
+			t.Errorf("\n  got: %d\n  want: %d", rec.Code, test.status)
+			return
+		}
+		if got := rec.Header().Get("Content-Type"); got != "application/json" {
+			t.Errorf("\n  got: %s\n  want: %s", got, "application/json")
+			return
+		}
+		if got := rec.Body.String(); got != test.body {
+			t.Errorf("\n  got: %s\n  want: %s", got, test.body)
+			return
+		}
+	}
+}
+
+type testResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *testResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func TestWriteHTTP2(t *testing.T) {
+	// 指定しない場合は何も書き込まない
+	w := &testResponseWriter{ResponseWriter: httptest.NewRecorder()}
+	WriteHTTP(w, nil)
+	if w.status != 0 {
+		t.Errorf("\n  got: %d\n  want: %d", w.status, 0)
+		return
+	}
+
+	w = &testResponseWriter{ResponseWriter: httptest.NewRecorder()}
+	WriteHTTP(w, nil, WithOKOnNil())
+	if w.status != 200 {
+		t.Errorf("\n  got: %d\n  want: %d", w.status, 200)
+		return
+	}
+}
+
+func TestWriteHeader1(t *testing.T) {
+	h := http.Header{}
+	W(New(codes.NotFound, "UserNotFound", "").WithDomain("example.com")).(*Error).WriteHeader(h)
+
+	want := map[string]string{
+		"X-Error-Code":   "NotFound",
+		"X-Error-Reason": "UserNotFound",
+		"X-Error-Domain": "example.com",
+	}
+	for k, v := range want {
+		if got := h.Get(k); got != v {
+			t.Errorf("%s\n  got: %s\n  want: %s", k, got, v)
+			return
+		}
+	}
+
+	// 値が空のヘッダーは設定しない
+	h = http.Header{}
+	New(codes.NotFound, "", "").WriteHeader(h)
+	if _, ok := h["X-Error-Reason"]; ok {
+		t.Errorf("\n  got: %v\n  want: no X-Error-Reason", h)
+		return
+	}
+	if _, ok := h["X-Error-Domain"]; ok {
+		t.Errorf("\n  got: %v\n  want: no X-Error-Domain", h)
+		return
+	}
+}
+
+func TestSanitizeHeaderValue1(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{src: "Reason", want: "Reason"},
+		{src: "Reason\r\nSet-Cookie: a=b", want: "ReasonSet-Cookie: a=b"},
+		{src: "Reason\nX-Injected: 1", want: "ReasonX-Injected: 1"},
+		{src: "a\x00b\x7fc", want: "abc"},
+		{src: "a\tb", want: "a\tb"},
+		{src: "  Reason  ", want: "Reason"},
+		{src: "\r\n", want: ""},
+	}
+	for _, test := range tests {
+		got := sanitizeHeaderValue(test.src)
+		if got != test.want {
+			t.Errorf("\n  got: %q\n  want: %q", got, test.want)
+			return
+		}
+	}
+
+	h := http.Header{}
+	New(codes.NotFound, "Reason\r\nX-Injected: 1", "").WriteHeader(h)
+	if h.Get("X-Injected") != "" || strings.ContainsAny(h.Get("X-Error-Reason"), "\r\n") {
+		t.Errorf("Header injection: %v", h)
+		return
+	}
+}
+
+func TestParseHTTPResponse1(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		code    codes.Code
+		reason  string
+		message string
+		domain  string
+	}{
+		{
+			name:    "JSON",
+			status:  404,
+			body:    `{"code":"NotFound","reason":"UserNotFound","message":"ユーザーが存在しません。","domain":"example.com"}`,
+			code:    codes.NotFound,
+			reason:  "UserNotFound",
+			message: "ユーザーが存在しません。",
+			domain:  "example.com",
+		},
+		{
+			name:    "ボディの code を優先",
+			status:  400,
+			body:    `{"code":"FailedPrecondition","reason":"FailedPrecondition","message":"","domain":""}`,
+			code:    codes.FailedPrecondition,
+			reason:  "FailedPrecondition",
+			message: "",
+		},
+		{
+			name:    "JSON 以外",
+			status:  503,
+			body:    "Service Unavailable",
+			code:    codes.Unavailable,
+			message: "Service Unavailable",
+		},
+		{
+			name:    "ers 以外の JSON",
+			status:  502,
+			body:    `{"error":"upstream timeout"}`,
+			code:    codes.Unknown,
+			message: `{"error":"upstream timeout"}`,
+		},
+		{
+			name:    "未知のステータス",
+			status:  418,
+			body:    "",
+			code:    codes.Unknown,
+			message: "I'm a teapot",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: test.status,
+				Body:       io.NopCloser(strings.NewReader(test.body)),
+			}
+
+			var err *Error
+			if !As(ParseHTTPResponse(resp), &err) {
+				t.Errorf("Expected *Error")
+				return
+			}
+			if err.Code() != test.code {
+				t.Errorf("\n  got: %s\n  want: %s", err.Code(), test.code)
+				return
+			}
+			if err.Reason() != test.reason {
+				t.Errorf("\n  got: %s\n  want: %s", err.Reason(), test.reason)
+				return
+			}
+			if err.Message() != test.message {
+				t.Errorf("\n  got: %s\n  want: %s", err.Message(), test.message)
+				return
+			}
+			if err.Domain() != test.domain {
+				t.Errorf("\n  got: %s\n  want: %s", err.Domain(), test.domain)
+				return
+			}
+		})
+	}
+}
+
+func TestParseHTTPResponse2(t *testing.T) {
+	for _, status := range []int{200, 201, 204} {
+		resp := &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(""))}
+		if err := ParseHTTPResponse(resp); err != nil {
+			t.Errorf("\n  got: %v\n  want: nil", err)
+			return
+		}
+	}
+
+	// 復元したエラーも OnError に通知する
+	var notified int
+	OnError = func(e *Error) { notified++ }
+	defer func() { OnError = nil }()
+	_ = ParseHTTPResponse(&http.Response{StatusCode: 500})
+	if notified != 1 {
+		t.Errorf("\n  got: %d\n  want: %d", notified, 1)
+		return
+	}
+
+	// レスポンスが無い場合は成功として扱わない
+	if err := ParseHTTPResponse(nil); !Is(err, ErrInternal) {
+		t.Errorf("\n  got: %v\n  want: %v", err, ErrInternal)
+		return
+	}
+
+	// WriteHTTP で書き込んだレスポンスから復元できる
+	rec := httptest.NewRecorder()
+	WriteHTTP(rec, ErrPermissionDenied.WithDomain("example.com"))
+	var err *Error
+	if !As(ParseHTTPResponse(rec.Result()), &err) || !Is(err, ErrPermissionDenied) || err.Domain() != "example.com" {
+		t.Errorf("\n  got: %v\n  want: %v", err, ErrPermissionDenied)
+		return
+	}
+}
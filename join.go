@@ -0,0 +1,66 @@
+package ers
+
+import (
+	"strings"
+
+	"google.golang.org/grpc/codes"
+)
+
+// joinError は, 複数のエラーを束ねたエラー.
+type joinError struct {
+	errs []error
+}
+
+// Join は, errs を束ねたエラーを返す.
+// nil はスキップし, すべて nil の場合は nil を返す.
+// 返り値は Is/As で束ねた個々のエラーに対してマッチする.
+func Join(errs ...error) error {
+	v := &joinError{}
+	for _, err := range errs {
+		if err != nil {
+			v.errs = append(v.errs, err)
+		}
+	}
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v
+}
+
+// Error は, 各エラーの文字列を改行で連結して返す.
+func (e *joinError) Error() string {
+	texts := make([]string, 0, len(e.errs))
+	for _, err := range e.errs {
+		texts = append(texts, err.Error())
+	}
+	return strings.Join(texts, "\n")
+}
+
+// Unwrap は, 束ねたエラーを返す.
+// Go 1.20 の複数ラップに対応しており, errors.Is/errors.As は全ブランチを辿る.
+// *Error 自体は単一のエラーのみを内包し, 従来どおり Unwrap() error を実装する.
+func (e *joinError) Unwrap() []error {
+	return e.errs
+}
+
+// Code は, 束ねたエラーのうち最初の OK でないコードを返す.
+func (e *joinError) Code() codes.Code {
+	for _, err := range e.errs {
+		if code := codeOf(err); code != codes.OK {
+			return code
+		}
+	}
+	return codes.OK
+}
+
+// Message は, 先頭のエラーのメッセージを返す.
+func (e *joinError) Message() string {
+	var v *Error
+	if As(e.errs[0], &v) {
+		return v.Message()
+	}
+	if err, ok := e.errs[0].(interface{ Message() string }); ok {
+		return err.Message()
+	}
+	return ""
+}
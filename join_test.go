@@ -0,0 +1,94 @@
+package ers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestJoin1(t *testing.T) {
+	if err := Join(); err != nil {
+		t.Errorf("\n  got: %v\n  want: nil", err)
+		return
+	}
+	if err := Join(nil, nil); err != nil {
+		t.Errorf("\n  got: %v\n  want: nil", err)
+		return
+	}
+}
+
+func TestJoin2(t *testing.T) {
+	errPtr := &testErrorPtr{}
+	err := Join(nil, ErrNotFound.WithTrace("1"), W(ErrInternal), errPtr)
+
+	want := "存在しないデータへの参照が発生しています。\nシステム内部でエラーが発生しました。\nptr"
+	if got := err.Error(); got != want {
+		t.Errorf("\n  got: %s\n  want: %s", got, want)
+		return
+	}
+
+	// 標準の errors パッケージと同じく個々のエラーにマッチする
+	for _, target := range []error{ErrNotFound, ErrInternal, errPtr} {
+		if !Is(err, target) || !errors.Is(err, target) {
+			t.Errorf("Expected to match %v", target)
+			return
+		}
+	}
+	if Is(err, ErrUnavailable) {
+		t.Errorf("Expected not to match %v", ErrUnavailable)
+		return
+	}
+
+	var ptr *testErrorPtr
+	if !As(err, &ptr) || ptr != errPtr {
+		t.Errorf("Expected As to find %v", errPtr)
+		return
+	}
+}
+
+func TestJoin3(t *testing.T) {
+	err := W(Join(W(&testErrorPtr{}, WithCode(codes.OK)), ErrNotFound, ErrInternal)).(*Error)
+
+	if got := err.Code(); got != codes.NotFound {
+		t.Errorf("\n  got: %s\n  want: %s", got, codes.NotFound)
+		return
+	}
+
+	err = W(Join(ErrInternal, ErrNotFound)).(*Error)
+	if got := err.Message(); got != ErrInternal.message {
+		t.Errorf("\n  got: %s\n  want: %s", got, ErrInternal.message)
+		return
+	}
+}
+
+func TestJoin4(t *testing.T) {
+	// *Error でラップした場合も標準の errors パッケージで全ブランチを辿れる
+	errPtr := &testErrorPtr{}
+	err := W(W(Join(ErrNotFound, W(errPtr), fmt.Errorf("wrap: %w", ErrInternal))))
+
+	for _, target := range []error{ErrNotFound, ErrInternal, errPtr} {
+		if !errors.Is(err, target) {
+			t.Errorf("Expected to match %v", target)
+			return
+		}
+	}
+
+	var ptr *testErrorPtr
+	if !errors.As(err, &ptr) || ptr != errPtr {
+		t.Errorf("Expected As to find %v", errPtr)
+		return
+	}
+
+	// 単一のラップは従来どおり Unwrap() error で辿る
+	if _, ok := err.(interface{ Unwrap() error }); !ok {
+		t.Errorf("Expected *Error to implement Unwrap() error")
+		return
+	}
+	inner := errors.Unwrap(errors.Unwrap(err))
+	if v, ok := inner.(interface{ Unwrap() []error }); !ok || len(v.Unwrap()) != 3 {
+		t.Errorf("Expected join to implement Unwrap() []error")
+		return
+	}
+}
@@ -0,0 +1,46 @@
+package ers
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// jsonError は, JSON に出力するエラーの形式.
+// trace や frame は機密情報を含む可能性があるため出力しない.
+type jsonError struct {
+	Code    string `json:"code"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+	Domain  string `json:"domain"`
+}
+
+// MarshalJSON は, ラップ先まで辿って解決した code/reason/message/domain を JSON にする.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonError{
+		Code:    e.Code().String(),
+		Reason:  e.Reason(),
+		Message: e.Message(),
+		Domain:  e.Domain(),
+	})
+}
+
+// UnmarshalJSON は, MarshalJSON で出力した JSON からエラーを復元する.
+func (e *Error) UnmarshalJSON(data []byte) error {
+	v := jsonError{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	code, ok := CodeFromString(v.Code)
+	if !ok {
+		return fmt.Errorf("ers: unknown code %q", v.Code)
+	}
+
+	*e = Error{
+		code:    code,
+		reason:  v.Reason,
+		message: v.Message,
+		domain:  v.Domain,
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package ers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestMarshalJSON1(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{
+			err:  ErrInternal.WithTrace("secret"),
+			want: `{"code":"Internal","reason":"Internal","message":"システム内部でエラーが発生しました。","domain":""}`,
+		},
+		{
+			err:  W(New(codes.NotFound, "UserNotFound", "ユーザーが存在しません。").WithDomain("example.com"), WithTrace("secret")),
+			want: `{"code":"NotFound","reason":"UserNotFound","message":"ユーザーが存在しません。","domain":"example.com"}`,
+		},
+	}
+	for _, test := range tests {
+		got, err := json.Marshal(test.err)
+		if err != nil {
+			t.Errorf("Failed to marshal: %s", err)
+			return
+		}
+		if string(got) != test.want {
+			t.Errorf("\n  got: %s\n  want: %s", got, test.want)
+			return
+		}
+	}
+}
+
+func TestUnmarshalJSON1(t *testing.T) {
+	src := W(New(codes.NotFound, "UserNotFound", "ユーザーが存在しません。").WithDomain("example.com")).(*Error)
+
+	data, err := json.Marshal(src)
+	if err != nil {
+		t.Errorf("Failed to marshal: %s", err)
+		return
+	}
+
+	got := &Error{}
+	if err := json.Unmarshal(data, got); err != nil {
+		t.Errorf("Failed to unmarshal: %s", err)
+		return
+	}
+
+	if got.Code() != src.Code() {
+		t.Errorf("\n  got: %s\n  want: %s", got.Code(), src.Code())
+		return
+	}
+	if got.Reason() != src.Reason() {
+		t.Errorf("\n  got: %s\n  want: %s", got.Reason(), src.Reason())
+		return
+	}
+	if got.Message() != src.Message() {
+		t.Errorf("\n  got: %s\n  want: %s", got.Message(), src.Message())
+		return
+	}
+	if got.Domain() != src.Domain() {
+		t.Errorf("\n  got: %s\n  want: %s", got.Domain(), src.Domain())
+		return
+	}
+}
+
+func TestUnmarshalJSON2(t *testing.T) {
+	got := &Error{}
+	if err := json.Unmarshal([]byte(`{"code":"NoSuchCode"}`), got); err == nil {
+		t.Errorf("Expected error for unknown code")
+		return
+	}
+}
@@ -0,0 +1,90 @@
+package ers
+
+import (
+	"sync"
+
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	localeMu sync.RWMutex
+	// 言語ごとのコードに対応するメッセージ
+	localeMessages = map[string]map[codes.Code]string{}
+)
+
+// RegisterMessages は, lang の言語で表示するコードごとのメッセージを登録する.
+// 同じ言語で複数回登録した場合はマージされ, 同じコードは後から登録したものが優先される.
+func RegisterMessages(lang string, msgs map[codes.Code]string) {
+	localeMu.Lock()
+	defer localeMu.Unlock()
+
+	v, ok := localeMessages[lang]
+	if !ok {
+		v = map[codes.Code]string{}
+		localeMessages[lang] = v
+	}
+	for code, msg := range msgs {
+		v[code] = msg
+	}
+}
+
+// LocalizedMessage は, lang の言語で登録されたコードに対応するメッセージを返す.
+// WithMessage や New の message でメッセージが明示的に指定されている場合はそれを優先する.
+// 未登録の言語やコードの場合は既定の Message を返す.
+func (e *Error) LocalizedMessage(lang string) string {
+	if msg, ok := e.explicitMessage(); ok {
+		return msg
+	}
+	if msg, ok := localeMessage(lang, e.Code()); ok {
+		return msg
+	}
+	return e.Message()
+}
+
+func localeMessage(lang string, code codes.Code) (string, bool) {
+	localeMu.RLock()
+	defer localeMu.RUnlock()
+
+	msg, ok := localeMessages[lang][code]
+	return msg, ok
+}
+
+// Locale は, WithLocale で指定されたロケールを外側の層から順に探して返す.
+// 指定されていない場合は空文字を返す.
+func (e *Error) Locale() string {
+	v := e
+	for i := 0; i < maxUnwrapDepth; i++ {
+		if v.locale != "" {
+			return v.locale
+		}
+		next, ok := v.error.(*Error)
+		if !ok {
+			return ""
+		}
+		v = next
+	}
+	return ""
+}
+
+// explicitMessage は, ラップ時や生成時に明示的に指定されたメッセージを返す.
+// 番兵エラーのメッセージや, コードに対応する定義済みエラーと同じメッセージは明示的な指定として扱わない.
+func (e *Error) explicitMessage() (string, bool) {
+	v := e
+	for i := 0; i < maxUnwrapDepth; i++ {
+		if v.isSource() {
+			if v.sentinel || v.message == "" || v.message == defaultMessage(v.code) {
+				break
+			}
+			return v.message, true
+		}
+		if v.message != "" {
+			return v.message, true
+		}
+		next, ok := v.error.(*Error)
+		if !ok {
+			break
+		}
+		v = next
+	}
+	return "", false
+}
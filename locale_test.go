@@ -0,0 +1,112 @@
+package ers
+
+import (
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+)
+
+func TestLocalizedMessage1(t *testing.T) {
+	RegisterMessages("x-test", map[codes.Code]string{
+		codes.NotFound: "not found",
+	})
+	RegisterMessages("x-test", map[codes.Code]string{
+		codes.Internal: "internal error",
+	})
+
+	tests := []struct {
+		err  *Error
+		lang string
+		want string
+	}{
+		{err: ErrNotFound, lang: "x-test", want: "not found"},
+		{err: ErrInternal, lang: "x-test", want: "internal error"},
+		{err: W(ErrNotFound).(*Error), lang: "x-test", want: "not found"},
+		// 未登録のコードや言語は既定のメッセージにフォールバックする
+		{err: ErrUnavailable, lang: "x-test", want: ErrUnavailable.message},
+		{err: ErrNotFound, lang: "x-unknown", want: ErrNotFound.message},
+	}
+	for _, test := range tests {
+		got := test.err.LocalizedMessage(test.lang)
+		if got != test.want {
+			t.Errorf("\n  got: %s\n  want: %s", got, test.want)
+			return
+		}
+	}
+}
+
+func TestLocalizedMessage2(t *testing.T) {
+	RegisterMessages("x-test", map[codes.Code]string{
+		codes.NotFound: "not found",
+	})
+
+	// WithMessage で指定したメッセージはロケールより優先される
+	err := W(W(ErrNotFound, WithMessage("user not found"))).(*Error)
+	if got := err.LocalizedMessage("x-test"); got != "user not found" {
+		t.Errorf("\n  got: %s\n  want: %s", got, "user not found")
+		return
+	}
+
+	// 生成時に指定したメッセージも優先される
+	src := NewWithOptions(codes.NotFound, "UserNotFound", WithMessage("ユーザーが存在しません。"), WithLocale("x-test"))
+	for _, err := range []*Error{src, W(src).(*Error), New(codes.NotFound, "UserNotFound", "ユーザーが存在しません。")} {
+		if got := err.LocalizedMessage("x-test"); got != "ユーザーが存在しません。" {
+			t.Errorf("\n  got: %s\n  want: %s", got, "ユーザーが存在しません。")
+			return
+		}
+	}
+	var got *errdetails.LocalizedMessage
+	for _, detail := range src.GRPCStatus().Details() {
+		if v, ok := detail.(*errdetails.LocalizedMessage); ok {
+			got = v
+		}
+	}
+	if got.GetMessage() != "ユーザーが存在しません。" {
+		t.Errorf("\n  got: %s\n  want: %s", got.GetMessage(), "ユーザーが存在しません。")
+		return
+	}
+
+	// 定義済みエラーと同じメッセージはロケールのメッセージにする
+	if got := NewAuto(codes.NotFound, "UserNotFound").LocalizedMessage("x-test"); got != "not found" {
+		t.Errorf("\n  got: %s\n  want: %s", got, "not found")
+		return
+	}
+}
+
+func TestWithLocale1(t *testing.T) {
+	RegisterMessages("x-test", map[codes.Code]string{
+		codes.NotFound: "not found",
+	})
+
+	tests := []struct {
+		err  *Error
+		want *errdetails.LocalizedMessage
+	}{
+		{err: W(ErrNotFound, WithLocale("x-test")).(*Error), want: &errdetails.LocalizedMessage{Locale: "x-test", Message: "not found"}},
+		{err: W(W(ErrNotFound, WithLocale("x-test"))).(*Error), want: &errdetails.LocalizedMessage{Locale: "x-test", Message: "not found"}},
+		{err: W(ErrUnavailable, WithLocale("x-test")).(*Error), want: &errdetails.LocalizedMessage{Locale: "x-test", Message: ErrUnavailable.message}},
+		// New や NewWithOptions で指定しても付与する
+		{err: New(codes.NotFound, "UserNotFound", "", WithLocale("x-test")), want: &errdetails.LocalizedMessage{Locale: "x-test", Message: "not found"}},
+		{err: NewWithOptions(codes.NotFound, "UserNotFound", WithLocale("x-test")), want: &errdetails.LocalizedMessage{Locale: "x-test", Message: "not found"}},
+		// ロケール未指定の場合は付与しない
+		{err: W(ErrNotFound).(*Error), want: nil},
+	}
+	for _, test := range tests {
+		// クライアント側では status の details から取り出す
+		var got *errdetails.LocalizedMessage
+		for _, detail := range test.err.GRPCStatus().Details() {
+			if v, ok := detail.(*errdetails.LocalizedMessage); ok {
+				got = v
+			}
+		}
+		if (got == nil) != (test.want == nil) {
+			t.Errorf("\n  got: %v\n  want: %v", got, test.want)
+			return
+		}
+		if got != nil && (got.GetLocale() != test.want.GetLocale() || got.GetMessage() != test.want.GetMessage()) {
+			t.Errorf("\n  got: %s/%s\n  want: %s/%s", got.GetLocale(), got.GetMessage(), test.want.GetLocale(), test.want.GetMessage())
+			return
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package ers
+
+import (
+	"log/slog"
+
+	"google.golang.org/grpc/codes"
+)
+
+// LogLevels は, LogLevel が返すコードごとのログレベル.
+// 利用者が上書きしてマッピングを変更できる.
+var LogLevels = map[codes.Code]slog.Level{
+	codes.OK:                 slog.LevelInfo,
+	codes.Canceled:           slog.LevelInfo,
+	codes.InvalidArgument:    slog.LevelWarn,
+	codes.NotFound:           slog.LevelWarn,
+	codes.AlreadyExists:      slog.LevelWarn,
+	codes.PermissionDenied:   slog.LevelWarn,
+	codes.ResourceExhausted:  slog.LevelWarn,
+	codes.FailedPrecondition: slog.LevelWarn,
+	codes.Aborted:            slog.LevelWarn,
+	codes.OutOfRange:         slog.LevelWarn,
+	codes.Unauthenticated:    slog.LevelWarn,
+	codes.Unknown:            slog.LevelError,
+	codes.DeadlineExceeded:   slog.LevelError,
+	codes.Unimplemented:      slog.LevelError,
+	codes.Internal:           slog.LevelError,
+	codes.Unavailable:        slog.LevelError,
+	codes.DataLoss:           slog.LevelError,
+}
+
+// LogValue は, slog で出力する際に code/reason/message/domain/trace を構造化フィールドとして展開する.
+// trace が空の場合は省略する.
+func (e *Error) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("code", e.CodeString()),
+		slog.String("reason", e.Reason()),
+		slog.String("message", e.Message()),
+		slog.String("domain", e.Domain()),
+	}
+	if e.trace != nil && e.trace.Text != "" {
+		attrs = append(attrs, slog.String("trace", e.trace.Text))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// LogLevel は, コードに対応するログレベルを LogLevels から返す.
+// LogLevels に含まれないコードは slog.LevelError とする.
+func (e *Error) LogLevel() slog.Level {
+	if v, ok := LogLevels[e.Code()]; ok {
+		return v
+	}
+	return slog.LevelError
+}
+
+// Fields は, code/reason/message/domain/trace/tags/metadata を logrus の WithFields などに渡せる形式で返す.
+// 空のフィールドは省略する. tags と metadata はラップチェーン全体から集める.
+func (e *Error) Fields() map[string]any {
+	fields := map[string]any{
+		"code": e.CodeString(),
+	}
+	if v := e.Reason(); v != "" {
+		fields["reason"] = v
+	}
+	if v := e.Message(); v != "" {
+		fields["message"] = v
+	}
+	if v := e.Domain(); v != "" {
+		fields["domain"] = v
+	}
+	if e.trace != nil && e.trace.Text != "" {
+		fields["trace"] = e.trace.Text
+	}
+	if v := AllTags(e); len(v) > 0 {
+		fields["tags"] = v
+	}
+	if v := e.Metadata(); len(v) > 0 {
+		fields["metadata"] = v
+	}
+	return fields
+}
+
+// Summary は, 運用通知向けに "[code] reason: message" 形式の 1 行のサマリを返す.
+// trace などの詳細は含めない.
+// message が空の場合は code に対応する定義済みエラーのメッセージを使い, reason が空の場合は "[code] message" とする.
+func (e *Error) Summary() string {
+	code := e.Code()
+	message := e.Message()
+	if message == "" {
+		message = defaultMessage(code)
+	}
+	summary := "[" + codeString(code) + "] "
+	if reason := e.Reason(); reason != "" {
+		summary += reason + ": "
+	}
+	return summary + message
+}
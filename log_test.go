@@ -0,0 +1,160 @@
+package ers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestLogValue1(t *testing.T) {
+	tests := []struct {
+		err  error
+		want map[string]any
+	}{
+		{
+			err: ErrNotFound.WithTrace("user_id: 1"),
+			want: map[string]any{
+				"code":    "NotFound",
+				"reason":  "NotFound",
+				"message": "存在しないデータへの参照が発生しています。",
+				"domain":  "",
+				"trace":   "user_id: 1",
+			},
+		},
+		{
+			err: W(ErrInternal),
+			want: map[string]any{
+				"code":    "Internal",
+				"reason":  "Internal",
+				"message": "システム内部でエラーが発生しました。",
+				"domain":  "",
+			},
+		},
+	}
+	for _, test := range tests {
+		buf := &bytes.Buffer{}
+		logger := slog.New(slog.NewJSONHandler(buf, nil))
+		logger.Error("failed", "err", test.err)
+
+		got := struct {
+			Err map[string]any `json:"err"`
+		}{}
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Errorf("Failed to unmarshal: %s", err)
+			return
+		}
+		if len(got.Err) != len(test.want) {
+			t.Errorf("\n  got: %v\n  want: %v", got.Err, test.want)
+			return
+		}
+		for k, v := range test.want {
+			if got.Err[k] != v {
+				t.Errorf("%s\n  got: %v\n  want: %v", k, got.Err[k], v)
+				return
+			}
+		}
+	}
+}
+
+func TestLogLevel1(t *testing.T) {
+	tests := []struct {
+		err  *Error
+		want slog.Level
+	}{
+		{err: New(codes.OK, "", ""), want: slog.LevelInfo},
+		{err: ErrNotFound, want: slog.LevelWarn},
+		{err: ErrInvalidArgument, want: slog.LevelWarn},
+		{err: ErrInternal, want: slog.LevelError},
+		{err: ErrDataLoss, want: slog.LevelError},
+		{err: W(ErrNotFound).(*Error), want: slog.LevelWarn},
+		{err: New(codes.Code(100), "", ""), want: slog.LevelError},
+	}
+	for _, test := range tests {
+		if got := test.err.LogLevel(); got != test.want {
+			t.Errorf("\n  got: %s\n  want: %s", got, test.want)
+			return
+		}
+	}
+
+	// マッピングを差し替えられる
+	defer func(v map[codes.Code]slog.Level) { LogLevels = v }(LogLevels)
+	LogLevels = map[codes.Code]slog.Level{codes.NotFound: slog.LevelDebug}
+	if got := ErrNotFound.LogLevel(); got != slog.LevelDebug {
+		t.Errorf("\n  got: %s\n  want: %s", got, slog.LevelDebug)
+		return
+	}
+}
+
+func TestLogLevel2(t *testing.T) {
+	// error レベル相当のエラーだけを出力する
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelError}))
+	for _, err := range []*Error{ErrNotFound, ErrInternal, ErrInvalidArgument, W(ErrDataLoss).(*Error)} {
+		logger.Log(context.Background(), err.LogLevel(), "failed", "err", err)
+	}
+
+	if got := bytes.Count(buf.Bytes(), []byte("\n")); got != 2 {
+		t.Errorf("\n  got: %d\n  want: %d", got, 2)
+		return
+	}
+}
+
+func TestFields1(t *testing.T) {
+	tests := []struct {
+		err  *Error
+		want map[string]any
+	}{
+		{
+			err: ErrNotFound.WithTrace("user_id: 1").(*Error),
+			want: map[string]any{
+				"code":    "NotFound",
+				"reason":  "NotFound",
+				"message": "存在しないデータへの参照が発生しています。",
+				"trace":   "user_id: 1",
+			},
+		},
+		{
+			err: W(W(New(codes.Unavailable, "", "", WithTags("db")), WithDomain("example.com")), WithTags("timeout"), WithMetadata(map[string]string{"table": "users"})).(*Error),
+			want: map[string]any{
+				"code":     "Unavailable",
+				"domain":   "example.com",
+				"tags":     []string{"timeout", "db"},
+				"metadata": map[string]string{"table": "users"},
+			},
+		},
+	}
+	for _, test := range tests {
+		// logrus の WithFields や zap.Any にそのまま渡せる
+		got := test.err.Fields()
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("\n  got: %v\n  want: %v", got, test.want)
+			return
+		}
+	}
+}
+
+func TestSummary1(t *testing.T) {
+	tests := []struct {
+		err  *Error
+		want string
+	}{
+		{err: ErrInternal, want: "[Internal] Internal: システム内部でエラーが発生しました。"},
+		{err: W(ErrInternal, WithTrace("secret")).(*Error), want: "[Internal] Internal: システム内部でエラーが発生しました。"},
+		{err: New(codes.NotFound, "UserNotFound", "ユーザーが存在しません。"), want: "[NotFound] UserNotFound: ユーザーが存在しません。"},
+		// message が空の場合は code に対応する既定のメッセージを使う
+		{err: New(codes.NotFound, "UserNotFound", ""), want: "[NotFound] UserNotFound: 存在しないデータへの参照が発生しています。"},
+		{err: New(codes.Unavailable, "", ""), want: "[Unavailable] " + ErrUnavailable.message},
+		{err: W(New(codes.NotFound, "UserNotFound", ""), WithCode(codes.Internal)).(*Error), want: "[Internal] UserNotFound: システム内部でエラーが発生しました。"},
+	}
+	for _, test := range tests {
+		if got := test.err.Summary(); got != test.want {
+			t.Errorf("\n  got: %s\n  want: %s", got, test.want)
+			return
+		}
+	}
+}
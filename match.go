@@ -0,0 +1,152 @@
+package ers
+
+import (
+	"regexp"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type MatchOption func(o *matchOptions)
+
+type matchOptions struct {
+	ignoreCode          bool
+	ignoreReason        bool
+	matchesStatusReason bool
+}
+
+// IgnoreCode は, IsWithOptions で code を比較しない.
+func IgnoreCode() MatchOption {
+	return func(o *matchOptions) {
+		o.ignoreCode = true
+	}
+}
+
+// IgnoreReason は, IsWithOptions で reason を比較しない.
+func IgnoreReason() MatchOption {
+	return func(o *matchOptions) {
+		o.ignoreReason = true
+	}
+}
+
+// MatchStatusReason は, IsWithOptions で target が gRPC の status の場合に,
+// code に加えて errdetails.ErrorInfo の reason の一致も要求する.
+func MatchStatusReason() MatchOption {
+	return func(o *matchOptions) {
+		o.matchesStatusReason = true
+	}
+}
+
+// IsReason は, err のラップチェーン中に reason が一致する *Error がある場合に true を返す.
+// code は比較しない.
+func IsReason(err error, reason string) bool {
+	return match(err, codes.OK, reason, matchOptions{ignoreCode: true})
+}
+
+// IsReasonMatch は, err のラップチェーン中に reason が pattern にマッチする *Error がある場合に true を返す.
+// reason にバージョンや ID が埋め込まれている場合に使う. pattern は呼び出しごとにコンパイルしないよう,
+// regexp.MustCompile などで事前にコンパイルしたものを渡す.
+// pattern が nil の場合は false を返す.
+func IsReasonMatch(err error, pattern *regexp.Regexp) bool {
+	if pattern == nil {
+		return false
+	}
+	found := false
+	Walk(err, func(err error) bool {
+		v, ok := err.(*Error)
+		if !ok || !v.hasOwnCode() {
+			return true
+		}
+		found = pattern.MatchString(v.Reason())
+		return !found
+	})
+	return found
+}
+
+// IsWithOptions は, err のラップチェーン中に target と code/reason が一致する *Error がある場合に true を返す.
+// 比較は Is と同じく各層の生成時の code/reason で行い, WithCode などで上書きした値は使わない.
+// options で比較しない項目を指定できる. 指定しない場合は Is と同じ結果を返す.
+// target が gRPC の status の場合は, MatchStatusReason を指定すると reason も比較する.
+// それ以外で target が *Error でない場合は Is と同じ結果を返す.
+func IsWithOptions(err error, target error, options ...MatchOption) bool {
+	o := matchOptions{}
+	for _, option := range options {
+		option(&o)
+	}
+	switch v := target.(type) {
+	case *Error:
+		if o.ignoreCode || o.ignoreReason {
+			return matchError(err, v, o)
+		}
+	case interface{ GRPCStatus() *status.Status }:
+		if o.matchesStatusReason {
+			return matchStatus(err, v.GRPCStatus())
+		}
+	}
+	return Is(err, target)
+}
+
+// FindByCode は, err のラップチェーン中で code を持つ *Error の層を返す.
+// 複数の層が一致する場合は最も外側の層を返す.
+// ラップ時に code や reason を指定していない層は, ラップ先のコードを返すだけのため対象としない.
+func FindByCode(err error, code codes.Code) (*Error, bool) {
+	return find(err, code, "", matchOptions{ignoreReason: true})
+}
+
+// FindByReason は, err のラップチェーン中で reason を持つ *Error の層を返す.
+// 複数の層が一致する場合は最も外側の層を返す.
+// ラップ時に code や reason を指定していない層は, ラップ先の reason を返すだけのため対象としない.
+func FindByReason(err error, reason string) (*Error, bool) {
+	return find(err, codes.OK, reason, matchOptions{ignoreCode: true})
+}
+
+// matchError は, err のラップチェーン中に (*Error).Is と同じ比較で target と一致する *Error がある場合に true を返す.
+func matchError(err error, target *Error, o matchOptions) bool {
+	found := false
+	Walk(err, func(err error) bool {
+		if v, ok := err.(*Error); ok {
+			found = v.matchError(target, o)
+		}
+		return !found
+	})
+	return found
+}
+
+// matchStatus は, err のラップチェーン中に s と code/reason が一致する *Error がある場合に true を返す.
+func matchStatus(err error, s *status.Status) bool {
+	found := false
+	Walk(err, func(err error) bool {
+		if v, ok := err.(*Error); ok {
+			found = v.matchStatus(s, true)
+		}
+		return !found
+	})
+	return found
+}
+
+func match(err error, code codes.Code, reason string, o matchOptions) bool {
+	_, found := find(err, code, reason, o)
+	return found
+}
+
+// find は, err のラップチェーン中で code/reason が一致する最も外側の *Error の層を返す.
+func find(err error, code codes.Code, reason string, o matchOptions) (*Error, bool) {
+	var found *Error
+	Walk(err, func(err error) bool {
+		v, ok := err.(*Error)
+		if !ok || !v.hasOwnCode() {
+			return true
+		}
+		if (o.ignoreCode || v.Code() == code) && (o.ignoreReason || v.Reason() == reason) {
+			found = v
+			return false
+		}
+		return true
+	})
+	return found, found != nil
+}
+
+// hasOwnCode は, ラップ元のエラーであるか, ラップ時に code や reason が明示的に指定された層の場合に true を返す.
+func (e *Error) hasOwnCode() bool {
+	return e.isSource() || e.overrideCode != nil || e.overrideReason != ""
+}
@@ -0,0 +1,168 @@
+package ers
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestIsReason1(t *testing.T) {
+	tests := []struct {
+		err    error
+		reason string
+		want   bool
+	}{
+		{err: New(codes.OK, "Skipped", ""), reason: "Skipped", want: true},
+		{err: W(W(New(codes.OK, "Skipped", ""))), reason: "Skipped", want: true},
+		{err: fmt.Errorf("wrap: %w", New(codes.OK, "Skipped", "")), reason: "Skipped", want: true},
+		{err: W(Join(ErrInternal, New(codes.OK, "Skipped", ""))), reason: "Skipped", want: true},
+		{err: W(&testErrorPtr{}, WithReason("Skipped")), reason: "Skipped", want: true},
+		{err: New(codes.OK, "Skipped", ""), reason: "Other", want: false},
+		{err: W(&testErrorPtr{}), reason: "", want: false},
+		{err: nil, reason: "Skipped", want: false},
+	}
+	for _, test := range tests {
+		if got := IsReason(test.err, test.reason); got != test.want {
+			t.Errorf("[%v %s]\n  got: %t\n  want: %t", test.err, test.reason, got, test.want)
+			return
+		}
+	}
+}
+
+func TestIsWithOptions1(t *testing.T) {
+	err := W(New(codes.NotFound, "UserNotFound", ""))
+
+	tests := []struct {
+		target  error
+		options []MatchOption
+		want    bool
+	}{
+		// 両方を比較する
+		{target: New(codes.NotFound, "UserNotFound", ""), want: true},
+		{target: New(codes.Internal, "UserNotFound", ""), want: false},
+		{target: New(codes.NotFound, "Other", ""), want: false},
+		// reason のみを比較する
+		{target: New(codes.Internal, "UserNotFound", ""), options: []MatchOption{IgnoreCode()}, want: true},
+		{target: New(codes.NotFound, "Other", ""), options: []MatchOption{IgnoreCode()}, want: false},
+		// code のみを比較する
+		{target: New(codes.NotFound, "Other", ""), options: []MatchOption{IgnoreReason()}, want: true},
+		{target: New(codes.Internal, "UserNotFound", ""), options: []MatchOption{IgnoreReason()}, want: false},
+		// *Error 以外は Is と同じ
+		{target: &testErrorPtr{}, want: false},
+	}
+	for i, test := range tests {
+		if got := IsWithOptions(err, test.target, test.options...); got != test.want {
+			t.Errorf("[%d]\n  got: %t\n  want: %t", i, got, test.want)
+			return
+		}
+	}
+}
+
+func TestIsWithOptions2(t *testing.T) {
+	tests := []struct {
+		err    error
+		target error
+	}{
+		// 上書きしたコードは Is と同じく比較に使わない
+		{err: W(ErrNotFound, WithCode(codes.Internal)), target: New(codes.Internal, "NotFound", "")},
+		{err: W(ErrNotFound, WithCode(codes.Internal)), target: ErrNotFound},
+		{err: W(ErrNotFound, WithReason("UserNotFound")), target: New(codes.NotFound, "UserNotFound", "")},
+		{err: fmt.Errorf("wrap: %w", W(ErrNotFound)), target: ErrNotFound},
+		{err: W(errors.New("error")), target: ErrNotFound},
+	}
+	for i, test := range tests {
+		want := errors.Is(test.err, test.target)
+		if got := IsWithOptions(test.err, test.target); got != want {
+			t.Errorf("[%d]\n  got: %t\n  want: %t", i, got, want)
+			return
+		}
+	}
+
+	// 比較しない項目を指定した場合も上書きした値は使わない
+	err := W(ErrNotFound, WithCode(codes.Internal))
+	if IsWithOptions(err, New(codes.Internal, "Other", ""), IgnoreReason()) {
+		t.Errorf("Expected not to match %v", err)
+		return
+	}
+	if !IsWithOptions(err, New(codes.Internal, "NotFound", ""), IgnoreCode()) {
+		t.Errorf("Expected to match %v", err)
+		return
+	}
+}
+
+func TestFindByCode1(t *testing.T) {
+	src := New(codes.NotFound, "UserNotFound", "")
+	mid := W(fmt.Errorf("repository: %w", src), WithCode(codes.NotFound)).(*Error)
+	err := W(W(mid), WithCode(codes.Internal))
+
+	tests := []struct {
+		code codes.Code
+		want *Error
+	}{
+		// 複数の層が一致する場合は最も外側を返す
+		{code: codes.NotFound, want: mid},
+		{code: codes.Internal, want: err.(*Error)},
+		{code: codes.Unavailable, want: nil},
+	}
+	for _, test := range tests {
+		got, ok := FindByCode(err, test.code)
+		if got != test.want || ok != (test.want != nil) {
+			t.Errorf("[%s]\n  got: %v, %t\n  want: %v", test.code, got, ok, test.want)
+			return
+		}
+	}
+}
+
+func TestFindByReason1(t *testing.T) {
+	src := New(codes.NotFound, "UserNotFound", "")
+	mid := W(src, WithReason("AccountNotFound")).(*Error)
+	err := W(W(mid, WithReason("UserNotFound")))
+
+	tests := []struct {
+		reason string
+		want   *Error
+	}{
+		// 複数の層が一致する場合は最も外側を返す
+		{reason: "UserNotFound", want: errors.Unwrap(err).(*Error)},
+		{reason: "AccountNotFound", want: mid},
+		{reason: "Other", want: nil},
+	}
+	for _, test := range tests {
+		got, ok := FindByReason(err, test.reason)
+		if got != test.want || ok != (test.want != nil) {
+			t.Errorf("[%s]\n  got: %v, %t\n  want: %v", test.reason, got, ok, test.want)
+			return
+		}
+	}
+}
+
+func TestIsReasonMatch1(t *testing.T) {
+	pattern := regexp.MustCompile(`^UserNotFound(V\d+)?$`)
+
+	tests := []struct {
+		err     error
+		pattern *regexp.Regexp
+		want    bool
+	}{
+		{err: New(codes.NotFound, "UserNotFoundV2", ""), pattern: pattern, want: true},
+		{err: New(codes.NotFound, "UserNotFound", ""), pattern: pattern, want: true},
+		{err: New(codes.NotFound, "UserNotFoundV", ""), pattern: pattern, want: false},
+		{err: New(codes.NotFound, "AccountNotFound", ""), pattern: pattern, want: false},
+		// 多段ラップでも各層の reason を辿る
+		{err: W(fmt.Errorf("wrap: %w", W(New(codes.NotFound, "UserNotFoundV3", "")))), pattern: pattern, want: true},
+		{err: W(New(codes.NotFound, "UserNotFoundV2", ""), WithReason("Other")), pattern: pattern, want: true},
+		{err: W(New(codes.NotFound, "Other", ""), WithReason("UserNotFoundV1")), pattern: pattern, want: true},
+		{err: fmt.Errorf("UserNotFound"), pattern: pattern, want: false},
+		{err: New(codes.NotFound, "UserNotFound", ""), pattern: nil, want: false},
+		{err: nil, pattern: pattern, want: false},
+	}
+	for _, test := range tests {
+		if got := IsReasonMatch(test.err, test.pattern); got != test.want {
+			t.Errorf("[%v %v]\n  got: %t\n  want: %t", test.err, test.pattern, got, test.want)
+			return
+		}
+	}
+}
@@ -1,9 +1,57 @@
 package ers
 
+import (
+	"math/rand"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+)
+
 type WrapOption func(o *wrapOptions)
 
 type wrapOptions struct {
-	Trace any
+	Trace    any
+	Code     *codes.Code
+	Message  string
+	Reason   string
+	Metadata map[string]string
+	Frame    int
+	Locale   string
+	Domain   string
+	Tags     []string
+	Help     []*errdetails.Help_Link
+
+	FieldViolations []*errdetails.BadRequest_FieldViolation
+	QuotaViolations []*errdetails.QuotaFailure_Violation
+	RetryDelay      *time.Duration
+	Snapshot        bool
+	EnvInfo         bool
+	SamplingRate    *float64
+
+	// sentinel は, NewR や Catalog で番兵エラーを定義する場合に指定する
+	sentinel bool
+}
+
+// randFloat64 は, WithSampling で使う [0.0, 1.0) の乱数を返す. テストで差し替えられるよう変数として持つ.
+var randFloat64 = rand.Float64
+
+// newWrapOptions は, options を適用した wrapOptions を返す.
+func newWrapOptions(options []WrapOption) wrapOptions {
+	o := wrapOptions{}
+	for _, option := range options {
+		option(&o)
+	}
+	return o
+}
+
+// sampled は, WithSampling の指定に従って trace と frame を記録するかどうかを返す.
+// WithSampling を指定していない場合は常に記録する.
+func (o wrapOptions) sampled() bool {
+	if o.SamplingRate == nil {
+		return true
+	}
+	return randFloat64() < *o.SamplingRate
 }
 
 // WithTrace sets the trace option.
@@ -12,3 +60,150 @@ func WithTrace(v any) WrapOption {
 		o.Trace = v
 	}
 }
+
+// WithCode sets the code option.
+// 指定した場合, ラップ先を辿らずにこのコードを返す.
+// NewWrap でのみ有効で, New などでエラーを生成する場合は無視する.
+func WithCode(code codes.Code) WrapOption {
+	return func(o *wrapOptions) {
+		o.Code = &code
+	}
+}
+
+// WithMessage sets the message option.
+// 指定した場合, ラップ先のメッセージより優先して表示用メッセージとして使われる.
+// 空文字の場合は指定しなかった場合と同じくラップ先のメッセージを使う.
+// Message や GRPCStatus などクライアントに返すメッセージのみを上書きし, Error の文字列はラップ先のままにする.
+func WithMessage(message string) WrapOption {
+	return func(o *wrapOptions) {
+		o.Message = message
+	}
+}
+
+// WithReason sets the reason option.
+// 指定した場合, ラップ先の reason より優先して Reason が返す値として使われる.
+// Is による比較には影響しない.
+// NewWrap でのみ有効で, New などでエラーを生成する場合は無視する.
+func WithReason(reason string) WrapOption {
+	return func(o *wrapOptions) {
+		o.Reason = reason
+	}
+}
+
+// WithMetadata sets the metadata option.
+// GRPCStatus では errdetails.ErrorInfo の Metadata にマージされる.
+// "Trace" キーは trace 用に予約されているため無視される.
+func WithMetadata(metadata map[string]string) WrapOption {
+	return func(o *wrapOptions) {
+		if o.Metadata == nil {
+			o.Metadata = map[string]string{}
+		}
+		for k, v := range metadata {
+			o.Metadata[k] = v
+		}
+	}
+}
+
+// WithDomain sets the domain option.
+// GRPCStatus では errdetails.ErrorInfo の Domain として使われる.
+func WithDomain(domain string) WrapOption {
+	return func(o *wrapOptions) {
+		o.Domain = domain
+	}
+}
+
+// WithTags sets the tags option.
+// "db" や "timeout" などのフィルタ用のタグを付与する. 複数回指定した場合は追加される.
+func WithTags(tags ...string) WrapOption {
+	return func(o *wrapOptions) {
+		o.Tags = append(o.Tags, tags...)
+	}
+}
+
+// WithHelp sets the help option.
+// GRPCStatus では errdetails.Help の Links として details に追加される.
+// 複数回指定した場合はリンクが追加される.
+func WithHelp(url string, description string) WrapOption {
+	return func(o *wrapOptions) {
+		o.Help = append(o.Help, &errdetails.Help_Link{Url: url, Description: description})
+	}
+}
+
+// WithFieldViolation sets the field violation option.
+// GRPCStatus では errdetails.BadRequest の FieldViolations として details に追加される.
+// 複数回指定した場合はフィールドが追加される.
+func WithFieldViolation(field string, description string) WrapOption {
+	return func(o *wrapOptions) {
+		o.FieldViolations = append(o.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: field, Description: description})
+	}
+}
+
+// WithQuotaFailure sets the quota failure option.
+// GRPCStatus では errdetails.QuotaFailure の Violations として details に追加される.
+// 複数回指定した場合は違反が追加される.
+// コードが codes.ResourceExhausted 以外のエラーでは details に追加せず, 無視する.
+func WithQuotaFailure(subject string, description string) WrapOption {
+	return func(o *wrapOptions) {
+		o.QuotaViolations = append(o.QuotaViolations, &errdetails.QuotaFailure_Violation{Subject: subject, Description: description})
+	}
+}
+
+// WithRetryDelay sets the retry delay option.
+// GRPCStatus では errdetails.RetryInfo の RetryDelay として details に追加される.
+// 0 を指定した場合も, 即時に再試行してよいことを表す RetryInfo として追加される.
+// 複数の層で指定した場合は外側の層が優先される.
+func WithRetryDelay(d time.Duration) WrapOption {
+	return func(o *wrapOptions) {
+		o.RetryDelay = &d
+	}
+}
+
+// WithSnapshot sets the snapshot option.
+// 指定した場合, NewWrap のラップ時にラップ先から解決した code/reason/message をラップした層に固定する.
+// 以降はラップ先を辿らずに固定した値を返す. WithCode などで明示した値はそちらを優先する.
+// NewWrap でのみ有効で, New などでエラーを生成する場合は無視する.
+func WithSnapshot() WrapOption {
+	return func(o *wrapOptions) {
+		o.Snapshot = true
+	}
+}
+
+// WithEnvInfo sets the env info option.
+// 指定した場合, エラーの生成時にホスト名とプロセス ID を "Host" と "PID" キーで Metadata に記録する.
+// GRPCStatus では errdetails.ErrorInfo の Metadata に含まれる.
+// ホスト名を取得できない場合は空文字を記録する.
+func WithEnvInfo() WrapOption {
+	return func(o *wrapOptions) {
+		o.EnvInfo = true
+	}
+}
+
+// WithSampling sets the sampling option.
+// 指定した場合, rate の割合でのみ trace と frame, スタックを記録する.
+// 高頻度で発生する同種のエラーで記録のコストを抑えるために使う.
+// 記録しなかった場合も code/reason/message などは保持する.
+// rate が 1 以上の場合は常に記録し, 0 以下の場合は記録しない.
+func WithSampling(rate float64) WrapOption {
+	return func(o *wrapOptions) {
+		o.SamplingRate = &rate
+	}
+}
+
+// WithLocale sets the locale option.
+// 指定した場合, GRPCStatus で RegisterMessages で登録した lang のメッセージを
+// errdetails.LocalizedMessage として details に追加する.
+func WithLocale(lang string) WrapOption {
+	return func(o *wrapOptions) {
+		o.Locale = lang
+	}
+}
+
+// WithFrame sets the frame option.
+// 記録する呼び出し元フレームを skip 段だけ呼び出し元側にずらす.
+// ヘルパー関数の中で New や NewWrap を呼ぶ場合に, ヘルパーの呼び出し元を記録するために使う.
+// New と NewWrap の両方で有効.
+func WithFrame(skip int) WrapOption {
+	return func(o *wrapOptions) {
+		o.Frame = skip
+	}
+}
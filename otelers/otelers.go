@@ -0,0 +1,41 @@
+// Package otelers は, ers パッケージのエラーを OpenTelemetry の span に記録するヘルパーを提供する.
+// OpenTelemetry への依存を ers パッケージに持ち込まないよう, 別モジュールとして分離している.
+package otelers
+
+import (
+	"github.com/tys-muta/go-ers"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	AttributeCode   = attribute.Key("ers.code")
+	AttributeReason = attribute.Key("ers.reason")
+	AttributeDomain = attribute.Key("ers.domain")
+)
+
+// RecordSpan は, err を span に記録し, code/reason/domain を属性として設定する.
+// span が nil または記録中でない場合は何もしない.
+// code が OK でない場合のみ span の status を Error にする.
+func RecordSpan(span trace.Span, err error) {
+	if span == nil || err == nil || !span.IsRecording() {
+		return
+	}
+
+	var e *ers.Error
+	if !ers.As(err, &e) {
+		e = ers.NewWrap(err).(*ers.Error)
+	}
+
+	span.RecordError(err)
+	span.SetAttributes(
+		AttributeCode.String(e.Code().String()),
+		AttributeReason.String(e.Reason()),
+		AttributeDomain.String(e.Domain()),
+	)
+	if e.Code() != codes.OK {
+		span.SetStatus(otelcodes.Error, e.Message())
+	}
+}
@@ -0,0 +1,81 @@
+package otelers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tys-muta/go-ers"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+)
+
+func record(t *testing.T, err error) sdktrace.ReadOnlySpan {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	_, span := provider.Tracer("test").Start(context.Background(), "test")
+	RecordSpan(span, err)
+	span.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("\n  got: %d spans\n  want: 1 span", len(spans))
+	}
+	return spans[0]
+}
+
+func TestRecordSpan1(t *testing.T) {
+	span := record(t, ers.W(ers.ErrNotFound.WithDomain("example.com")))
+
+	want := map[attribute.Key]string{
+		AttributeCode:   "NotFound",
+		AttributeReason: "NotFound",
+		AttributeDomain: "example.com",
+	}
+	got := map[attribute.Key]string{}
+	for _, attr := range span.Attributes() {
+		got[attr.Key] = attr.Value.AsString()
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s\n  got: %s\n  want: %s", k, got[k], v)
+			return
+		}
+	}
+
+	if span.Status().Code != otelcodes.Error {
+		t.Errorf("\n  got: %s\n  want: %s", span.Status().Code, otelcodes.Error)
+		return
+	}
+	if len(span.Events()) != 1 || span.Events()[0].Name != "exception" {
+		t.Errorf("\n  got: %v\n  want: exception event", span.Events())
+		return
+	}
+}
+
+func TestRecordSpan2(t *testing.T) {
+	// code が OK の場合は status を Error にしない
+	span := record(t, ers.New(codes.OK, "OK", ""))
+
+	if span.Status().Code == otelcodes.Error {
+		t.Errorf("\n  got: %s\n  want: not %s", span.Status().Code, otelcodes.Error)
+		return
+	}
+}
+
+func TestRecordSpan3(t *testing.T) {
+	// nil や記録中でない span でも panic しない
+	RecordSpan(nil, ers.ErrInternal)
+	RecordSpan(trace.SpanFromContext(context.Background()), ers.ErrInternal)
+
+	span := record(t, nil)
+	if len(span.Attributes()) != 0 {
+		t.Errorf("\n  got: %v\n  want: no attributes", span.Attributes())
+		return
+	}
+}
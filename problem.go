@@ -0,0 +1,66 @@
+package ers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ContentTypeProblemJSON は, RFC 7807 で定められた problem+json の Content-Type.
+const ContentTypeProblemJSON = "application/problem+json"
+
+// problemTypeDefault は, WithHelp で URL が指定されていない場合の type.
+const problemTypeDefault = "about:blank"
+
+// problemJSON は, RFC 7807 の problem details の形式.
+// 拡張メンバーとして code を含める.
+type problemJSON struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail"`
+	Code   string `json:"code"`
+}
+
+// ProblemJSON は, RFC 7807 の problem+json 形式の JSON を返す.
+// type は WithHelp で最初に指定された URL (無い場合は "about:blank"), title は reason,
+// status は HTTPStatus, detail は message とする. reason が空の場合は title にコードの名前を使う.
+// trace や frame は機密情報を含む可能性があるため出力しない.
+func (e *Error) ProblemJSON() ([]byte, error) {
+	v := problemJSON{
+		Type:   problemTypeDefault,
+		Title:  e.Reason(),
+		Status: e.HTTPStatus(),
+		Detail: e.Message(),
+		Code:   e.CodeString(),
+	}
+	if links := helpLinks(e); len(links) > 0 && links[0].GetUrl() != "" {
+		v.Type = links[0].GetUrl()
+	}
+	if v.Title == "" {
+		v.Title = v.Code
+	}
+	return json.Marshal(v)
+}
+
+// WriteProblemJSON は, err に対応するステータスコードと problem+json のボディを w に書き込む.
+// Content-Type は ContentTypeProblemJSON とする. *Error 以外のエラーはラップして書き込む.
+// err が nil の場合は何も書き込まない.
+func WriteProblemJSON(w http.ResponseWriter, err error) {
+	if err == nil {
+		return
+	}
+
+	var v *Error
+	if !As(err, &v) {
+		v = NewWrap(err).(*Error)
+	}
+	body, err := v.ProblemJSON()
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentTypeProblemJSON)
+	w.WriteHeader(v.HTTPStatus())
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,68 @@
+package ers
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestProblemJSON1(t *testing.T) {
+	tests := []struct {
+		err  *Error
+		want string
+	}{
+		{
+			err:  ErrNotFound.WithTrace("secret").(*Error),
+			want: `{"type":"about:blank","title":"NotFound","status":404,"detail":"存在しないデータへの参照が発生しています。","code":"NotFound"}`,
+		},
+		{
+			err:  W(New(codes.FailedPrecondition, "AccountLocked", "アカウントがロックされています。"), WithHelp("https://example.com/docs/locked", "ロック解除の手順")).(*Error),
+			want: `{"type":"https://example.com/docs/locked","title":"AccountLocked","status":400,"detail":"アカウントがロックされています。","code":"FailedPrecondition"}`,
+		},
+		{
+			// reason が空の場合は title にコードの名前を使う
+			err:  W(errors.New("plain")).(*Error),
+			want: `{"type":"about:blank","title":"Unknown","status":500,"detail":"","code":"Unknown"}`,
+		},
+	}
+	for _, test := range tests {
+		got, err := test.err.ProblemJSON()
+		if err != nil {
+			t.Errorf("Failed to marshal: %s", err)
+			return
+		}
+		if string(got) != test.want {
+			t.Errorf("\n  got: %s\n  want: %s", got, test.want)
+			return
+		}
+	}
+}
+
+func TestWriteProblemJSON1(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteProblemJSON(rec, W(ErrUnauthenticated))
+
+	if rec.Code != 401 {
+		t.Errorf("\n  got: %d\n  want: %d", rec.Code, 401)
+		return
+	}
+	if got := rec.Header().Get("Content-Type"); got != ContentTypeProblemJSON {
+		t.Errorf("\n  got: %s\n  want: %s", got, ContentTypeProblemJSON)
+		return
+	}
+	want := `{"type":"about:blank","title":"Unauthenticated","status":401,"detail":"認証できませんでした。","code":"Unauthenticated"}`
+	if got := rec.Body.String(); got != want {
+		t.Errorf("\n  got: %s\n  want: %s", got, want)
+		return
+	}
+
+	// nil の場合は何も書き込まない
+	rec = httptest.NewRecorder()
+	WriteProblemJSON(rec, nil)
+	if rec.Body.Len() != 0 || rec.Header().Get("Content-Type") != "" {
+		t.Errorf("\n  got: %s\n  want: empty", rec.Body.String())
+		return
+	}
+}
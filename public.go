@@ -0,0 +1,14 @@
+package ers
+
+// Public は, 外部のユーザーに公開するために内部の情報を取り除いたエラーを返す.
+// ラップ先まで辿って解決した code と reason のみを残し, message は code に対応する定義済みエラーのメッセージに置き換える.
+// trace, frame, スタック, domain, metadata, ラップ先のエラーは引き継がない.
+// レシーバのエラーは変更しない.
+func (e *Error) Public() *Error {
+	code := e.Code()
+	return &Error{
+		code:    code,
+		reason:  e.Reason(),
+		message: defaultMessage(code),
+	}
+}
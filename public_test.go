@@ -0,0 +1,81 @@
+package ers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestPublic1(t *testing.T) {
+	src := W(
+		New(codes.Internal, "DatabaseError", "connection to db-primary:5432 refused").WithDomain("internal.example.com"),
+		WithTrace("password=secret"),
+		WithMetadata(map[string]string{"host": "db-primary"}),
+		WithMessage("SELECT * FROM users failed"),
+	).(*Error)
+	got := src.Public()
+
+	if got.Code() != codes.Internal {
+		t.Errorf("\n  got: %s\n  want: %s", got.Code(), codes.Internal)
+		return
+	}
+	if got.Reason() != "DatabaseError" {
+		t.Errorf("\n  got: %s\n  want: %s", got.Reason(), "DatabaseError")
+		return
+	}
+	if got.Message() != "システム内部でエラーが発生しました。" {
+		t.Errorf("\n  got: %s\n  want: %s", got.Message(), "システム内部でエラーが発生しました。")
+		return
+	}
+	if got.trace != nil || got.frame != (Error{}).frame || got.stack != nil || got.metadata != nil || got.domain != "" || got.error != nil {
+		t.Errorf("Expected internal fields to be removed: %#v", got)
+		return
+	}
+
+	// どの出力形式でも機密情報が漏れない
+	marshaled, _ := json.Marshal(got)
+	outputs := []string{
+		got.Error(),
+		fmt.Sprintf("%v", got),
+		fmt.Sprintf("%+v", got),
+		string(marshaled),
+		got.GRPCStatus().Message(),
+		fmt.Sprint(errorInfoOf(t, got.GRPCStatus())),
+		fmt.Sprint(got.Metadata()),
+		got.Snapshot(),
+	}
+	for _, output := range outputs {
+		for _, secret := range []string{"db-primary", "secret", "SELECT", "internal.example.com", "public_test.go"} {
+			if strings.Contains(output, secret) {
+				t.Errorf("\n  got: %s\n  want: not contains %s", output, secret)
+				return
+			}
+		}
+	}
+
+	// 元のエラーは変更しない
+	if src.Message() != "SELECT * FROM users failed" {
+		t.Errorf("\n  got: %s\n  want: %s", src.Message(), "SELECT * FROM users failed")
+		return
+	}
+}
+
+func TestPublic2(t *testing.T) {
+	tests := []struct {
+		err  *Error
+		want string
+	}{
+		{err: ErrNotFound.WithTrace("user_id: 1").(*Error), want: ErrNotFound.message},
+		{err: W(&testErrorPtr{}, WithCode(codes.Unavailable)).(*Error), want: ErrUnavailable.message},
+		{err: New(codes.OK, "OK", "ok"), want: ""},
+	}
+	for _, test := range tests {
+		if got := test.err.Public().Message(); got != test.want {
+			t.Errorf("\n  got: %s\n  want: %s", got, test.want)
+			return
+		}
+	}
+}
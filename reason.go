@@ -0,0 +1,35 @@
+package ers
+
+import "google.golang.org/grpc/codes"
+
+// Reason は, reason を文字列のタイポから守るための型.
+type Reason string
+
+// 定義済みエラーの reason
+const (
+	ReasonCanceled           Reason = "Canceled"
+	ReasonUnknown            Reason = "Unknown"
+	ReasonInvalidArgument    Reason = "InvalidArgument"
+	ReasonDeadlineExceeded   Reason = "DeadlineExceeded"
+	ReasonNotFound           Reason = "NotFound"
+	ReasonAlreadyExists      Reason = "AlreadyExists"
+	ReasonPermissionDenied   Reason = "PermissionDenied"
+	ReasonResourceExhausted  Reason = "ResourceExhausted"
+	ReasonFailedPrecondition Reason = "FailedPrecondition"
+	ReasonAborted            Reason = "Aborted"
+	ReasonOutOfRange         Reason = "OutOfRange"
+	ReasonUnimplemented      Reason = "Unimplemented"
+	ReasonInternal           Reason = "Internal"
+	ReasonUnavailable        Reason = "Unavailable"
+	ReasonDataLoss           Reason = "DataLoss"
+	ReasonUnauthenticated    Reason = "Unauthenticated"
+)
+
+// NewR は, reason を Reason 型で受け取る New.
+// New と同じエラーを生成するため, Is では同じ reason 文字列を持つエラーと一致する.
+// パッケージ変数として定義する番兵エラー向けのため, OldestAge や Fingerprint では発生箇所として扱わない.
+func NewR(code codes.Code, reason Reason, message string, options ...WrapOption) *Error {
+	o := newWrapOptions(options)
+	o.sentinel = true
+	return newError(code, string(reason), message, o)
+}
@@ -0,0 +1,88 @@
+package ers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestNewR1(t *testing.T) {
+	const ReasonUserNotFound Reason = "UserNotFound"
+
+	err := NewR(codes.NotFound, ReasonUserNotFound, "ユーザーが存在しません。")
+	if err.Reason() != string(ReasonUserNotFound) {
+		t.Errorf("\n  got: %s\n  want: %s", err.Reason(), ReasonUserNotFound)
+		return
+	}
+
+	// New と同じ reason 文字列を持つエラーとは Is で一致する
+	if !Is(W(err), New(codes.NotFound, "UserNotFound", "")) {
+		t.Errorf("Expected to match by code and reason")
+		return
+	}
+}
+
+func TestReason1(t *testing.T) {
+	tests := []struct {
+		err    *Error
+		reason Reason
+	}{
+		{err: ErrCanceled, reason: ReasonCanceled},
+		{err: ErrUnknown, reason: ReasonUnknown},
+		{err: ErrInvalidArgument, reason: ReasonInvalidArgument},
+		{err: ErrDeadlineExceeded, reason: ReasonDeadlineExceeded},
+		{err: ErrNotFound, reason: ReasonNotFound},
+		{err: ErrAlreadyExists, reason: ReasonAlreadyExists},
+		{err: ErrPermissionDenied, reason: ReasonPermissionDenied},
+		{err: ErrResourceExhausted, reason: ReasonResourceExhausted},
+		{err: ErrFailedPrecondition, reason: ReasonFailedPrecondition},
+		{err: ErrAborted, reason: ReasonAborted},
+		{err: ErrOutOfRange, reason: ReasonOutOfRange},
+		{err: ErrUnimplemented, reason: ReasonUnimplemented},
+		{err: ErrInternal, reason: ReasonInternal},
+		{err: ErrUnavailable, reason: ReasonUnavailable},
+		{err: ErrDataLoss, reason: ReasonDataLoss},
+		{err: ErrUnauthenticated, reason: ReasonUnauthenticated},
+	}
+	for _, test := range tests {
+		if test.err.Reason() != string(test.reason) {
+			t.Errorf("\n  got: %s\n  want: %s", test.err.Reason(), test.reason)
+			return
+		}
+		if !Is(NewR(test.err.Code(), test.reason, ""), test.err) {
+			t.Errorf("Expected to match %v", test.err)
+			return
+		}
+	}
+}
+
+func TestReason2(t *testing.T) {
+	tests := []struct {
+		err  *Error
+		want string
+	}{
+		{err: W(ErrNotFound).(*Error), want: "NotFound"},
+		// fmt.Errorf を挟んでも Code と同じく最も近い *Error の reason を辿る
+		{err: W(fmt.Errorf("x: %w", ErrNotFound)).(*Error), want: "NotFound"},
+		{err: W(fmt.Errorf("x: %w", W(ErrNotFound, WithReason("UserNotFound")))).(*Error), want: "UserNotFound"},
+		{err: W(fmt.Errorf("x: %w", ErrNotFound), WithReason("Outer")).(*Error), want: "Outer"},
+		// 外部の status は ErrorInfo の reason を使う
+		{err: W(ErrNotFound.GRPCStatus().Err()).(*Error), want: "NotFound"},
+		{err: W(fmt.Errorf("x: %w", errors.New("plain"))).(*Error), want: ""},
+	}
+	for _, test := range tests {
+		if got := test.err.Reason(); got != test.want {
+			t.Errorf("\n  got: %s\n  want: %s", got, test.want)
+			return
+		}
+	}
+
+	// GRPCStatus の ErrorInfo にも反映される
+	err := W(fmt.Errorf("x: %w", ErrNotFound)).(*Error)
+	if got := errorInfoOf(t, err.GRPCStatus()).GetReason(); got != "NotFound" {
+		t.Errorf("\n  got: %s\n  want: %s", got, "NotFound")
+		return
+	}
+}
@@ -0,0 +1,40 @@
+package ers
+
+import (
+	"fmt"
+
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc/codes"
+)
+
+// Recover は, recover の戻り値を codes.Internal のエラーに変換する.
+// recovered が error の場合はそれをラップし, それ以外の場合は fmt.Sprintf で message にする.
+// スタックは panic が発生した箇所を含む形で記録する.
+// recovered が nil の場合は nil を返す.
+//
+//	defer func() {
+//		if r := recover(); r != nil {
+//			err = ers.Recover(r)
+//		}
+//	}()
+func Recover(recovered any) *Error {
+	if recovered == nil {
+		return nil
+	}
+
+	e := &Error{
+		code:      codes.Internal,
+		reason:    string(ReasonInternal),
+		message:   ErrInternal.message,
+		frame:     xerrors.Caller(1),
+		stack:     callers(1),
+		createdAt: nowFunc(),
+		trace:     NewTrace(fmt.Sprintf("panic: %v", recovered)),
+	}
+	if err, ok := recovered.(error); ok {
+		e.error = err
+	} else {
+		e.message = fmt.Sprintf("%v", recovered)
+	}
+	return notify(e)
+}
@@ -0,0 +1,63 @@
+package ers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func recoverTestPanic(v any) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = Recover(r)
+		}
+	}()
+	panic(v)
+}
+
+func TestRecover1(t *testing.T) {
+	src := errors.New("boom")
+	err := recoverTestPanic(src)
+
+	if !IsCode(err, codes.Internal) || !Is(err, ErrInternal) {
+		t.Errorf("\n  got: %v\n  want: %v", err, ErrInternal)
+		return
+	}
+	if !errors.Is(err, src) {
+		t.Errorf("Expected to match %v", src)
+		return
+	}
+	if got := err.(*Error).trace.Text; got != "panic: boom" {
+		t.Errorf("\n  got: %s\n  want: %s", got, "panic: boom")
+		return
+	}
+}
+
+func TestRecover2(t *testing.T) {
+	err := recoverTestPanic(42).(*Error)
+
+	if err.Code() != codes.Internal {
+		t.Errorf("\n  got: %s\n  want: %s", err.Code(), codes.Internal)
+		return
+	}
+	if got := err.Message(); got != "42" {
+		t.Errorf("\n  got: %s\n  want: %s", got, "42")
+		return
+	}
+
+	// panic が発生した関数がスタックに含まれる
+	if got := fmt.Sprintf("%+v", err); !strings.Contains(got, "recoverTestPanic") || !strings.Contains(got, "recover_test.go") {
+		t.Errorf("\n  got: %s\n  want: contains %s", got, "recoverTestPanic")
+		return
+	}
+}
+
+func TestRecover3(t *testing.T) {
+	if err := Recover(nil); err != nil {
+		t.Errorf("\n  got: %v\n  want: <nil>", err)
+		return
+	}
+}
@@ -0,0 +1,22 @@
+package ers
+
+import (
+	"google.golang.org/grpc/codes"
+)
+
+// RetryableCodes は, IsRetryable でリトライ可能と判定するコードの集合.
+// 利用者が上書きして判定対象を変更できる.
+var RetryableCodes = map[codes.Code]bool{
+	codes.Unavailable:       true,
+	codes.DeadlineExceeded:  true,
+	codes.ResourceExhausted: true,
+	codes.Aborted:           true,
+}
+
+// IsRetryable は, err のコードが RetryableCodes に含まれる場合に true を返す.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	return RetryableCodes[codeOf(err)]
+}
@@ -0,0 +1,58 @@
+package ers
+
+import (
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestIsRetryable1(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: nil, want: false},
+		{err: ErrUnavailable, want: true},
+		{err: ErrDeadlineExceeded, want: true},
+		{err: ErrResourceExhausted, want: true},
+		{err: ErrAborted, want: true},
+		{err: ErrInternal, want: false},
+		{err: ErrNotFound, want: false},
+		{err: W(ErrUnavailable), want: true},
+		{err: W(W(ErrAborted)), want: true},
+		{err: W(ErrInternal), want: false},
+		{err: fmt.Errorf("wrap: %w", ErrUnavailable), want: true},
+		{err: status.Error(codes.Unavailable, ""), want: true},
+		{err: W(status.Error(codes.Unavailable, "")), want: true},
+		{err: fmt.Errorf("x: %w", status.Error(codes.Unavailable, "")), want: true},
+		{err: fmt.Errorf("x: %w", fmt.Errorf("y: %w", status.Error(codes.Aborted, ""))), want: true},
+		{err: fmt.Errorf("x: %w", status.Error(codes.Internal, "")), want: false},
+		{err: W(&testErrorPtr{}, WithCode(codes.Unavailable)), want: true},
+		{err: &testErrorPtr{}, want: false},
+	}
+	for _, test := range tests {
+		got := IsRetryable(test.err)
+		if got != test.want {
+			t.Errorf("[%v] got: %t, want: %t", test.err, got, test.want)
+			return
+		}
+	}
+}
+
+func TestIsRetryable2(t *testing.T) {
+	backup := RetryableCodes
+	defer func() { RetryableCodes = backup }()
+
+	RetryableCodes = map[codes.Code]bool{codes.Internal: true}
+
+	if !IsRetryable(ErrInternal) {
+		t.Errorf("Expected Internal to be retryable")
+		return
+	}
+	if IsRetryable(ErrUnavailable) {
+		t.Errorf("Expected Unavailable not to be retryable")
+		return
+	}
+}
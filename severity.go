@@ -0,0 +1,46 @@
+package ers
+
+import (
+	"google.golang.org/grpc/codes"
+)
+
+// Severities は, Severity が返すコードごとの重大度のスコア.
+// スコアが大きいほど重大であり, 利用者が上書きしてアラートの制御を変更できる.
+var Severities = map[codes.Code]int{
+	codes.OK:                 0,
+	codes.Canceled:           1,
+	codes.NotFound:           2,
+	codes.AlreadyExists:      2,
+	codes.InvalidArgument:    3,
+	codes.OutOfRange:         3,
+	codes.FailedPrecondition: 3,
+	codes.Aborted:            3,
+	codes.PermissionDenied:   3,
+	codes.Unauthenticated:    3,
+	codes.ResourceExhausted:  4,
+	codes.DeadlineExceeded:   4,
+	codes.Unavailable:        4,
+	codes.Unimplemented:      4,
+	codes.Unknown:            5,
+	codes.Internal:           5,
+	codes.DataLoss:           5,
+}
+
+// Severity は, code の重大度のスコアを Severities から返す.
+// Severities に含まれないコードは codes.Unknown と同じスコアとする.
+func Severity(code codes.Code) int {
+	if v, ok := Severities[code]; ok {
+		return v
+	}
+	return Severities[codes.Unknown]
+}
+
+// IsMoreSevereThan は, e のコードの重大度が other より大きい場合に true を返す.
+// other が nil の場合は codes.OK として比較する.
+func (e *Error) IsMoreSevereThan(other *Error) bool {
+	code := codes.OK
+	if other != nil {
+		code = other.Code()
+	}
+	return Severity(e.Code()) > Severity(code)
+}
@@ -0,0 +1,75 @@
+package ers
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestSeverity1(t *testing.T) {
+	tests := []struct {
+		code codes.Code
+		want int
+	}{
+		{code: codes.OK, want: 0},
+		{code: codes.Canceled, want: 1},
+		{code: codes.Unknown, want: 5},
+		{code: codes.InvalidArgument, want: 3},
+		{code: codes.DeadlineExceeded, want: 4},
+		{code: codes.NotFound, want: 2},
+		{code: codes.AlreadyExists, want: 2},
+		{code: codes.PermissionDenied, want: 3},
+		{code: codes.ResourceExhausted, want: 4},
+		{code: codes.FailedPrecondition, want: 3},
+		{code: codes.Aborted, want: 3},
+		{code: codes.OutOfRange, want: 3},
+		{code: codes.Unimplemented, want: 4},
+		{code: codes.Internal, want: 5},
+		{code: codes.Unavailable, want: 4},
+		{code: codes.DataLoss, want: 5},
+		{code: codes.Unauthenticated, want: 3},
+		{code: codes.Code(100), want: 5},
+	}
+	for _, test := range tests {
+		if got := Severity(test.code); got != test.want {
+			t.Errorf("[%s]\n  got: %d\n  want: %d", test.code, got, test.want)
+			return
+		}
+	}
+}
+
+func TestSeverity2(t *testing.T) {
+	// テーブルを差し替えて重大度を変更できる
+	defer func(v map[codes.Code]int) { Severities = v }(Severities)
+	Severities = map[codes.Code]int{codes.NotFound: 10, codes.Unknown: 1}
+
+	if got := Severity(codes.NotFound); got != 10 {
+		t.Errorf("\n  got: %d\n  want: %d", got, 10)
+		return
+	}
+	if got := Severity(codes.Internal); got != 1 {
+		t.Errorf("\n  got: %d\n  want: %d", got, 1)
+		return
+	}
+}
+
+func TestIsMoreSevereThan1(t *testing.T) {
+	tests := []struct {
+		err   *Error
+		other *Error
+		want  bool
+	}{
+		{err: ErrInternal, other: ErrNotFound, want: true},
+		{err: ErrNotFound, other: ErrInternal, want: false},
+		{err: ErrInternal, other: ErrDataLoss, want: false},
+		{err: ErrInvalidArgument, other: ErrNotFound, want: true},
+		{err: W(ErrInternal).(*Error), other: W(ErrNotFound).(*Error), want: true},
+		{err: ErrNotFound, other: nil, want: true},
+	}
+	for _, test := range tests {
+		if got := test.err.IsMoreSevereThan(test.other); got != test.want {
+			t.Errorf("[%v > %v]\n  got: %t\n  want: %t", test.err, test.other, got, test.want)
+			return
+		}
+	}
+}
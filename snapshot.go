@@ -0,0 +1,24 @@
+package ers
+
+import (
+	"strings"
+)
+
+// Snapshot は, ゴールデンテスト用に実行環境に依存しない決定的な文字列を返す.
+// code/reason/message/domain とラップチェーン全体の trace.Text のみを出力し,
+// frame やスタック, trace の Values/Fields は含めない.
+// trace.Text はルート (最も内側) から順に出力する.
+func (e *Error) Snapshot() string {
+	lines := []string{
+		"code: " + e.Code().String(),
+		"reason: " + e.Reason(),
+		"message: " + e.Message(),
+		"domain: " + e.Domain(),
+	}
+	for _, trace := range e.Traces() {
+		if trace.Text != "" {
+			lines = append(lines, "trace: "+trace.Text)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
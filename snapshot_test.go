@@ -0,0 +1,44 @@
+package ers
+
+import (
+	"strings"
+	"testing"
+)
+
+func snapshotTestHelper() error {
+	trace := NewTrace("user_id: 1")
+	trace.Values = []any{struct{ Secret string }{Secret: "value"}}
+	return W(ErrNotFound.WithDomain("example.com").WithTrace(trace), WithTrace("repository"))
+}
+
+func TestSnapshot1(t *testing.T) {
+	want := strings.Join([]string{
+		"code: NotFound",
+		"reason: NotFound",
+		"message: 存在しないデータへの参照が発生しています。",
+		"domain: example.com",
+		"trace: user_id: 1",
+		"trace: repository",
+	}, "\n")
+
+	// 生成した場所が異なっても同一の出力になる
+	for _, err := range []error{snapshotTestHelper(), W(ErrNotFound.WithDomain("example.com").WithTrace("user_id: 1"), WithTrace("repository"))} {
+		got := err.(*Error).Snapshot()
+		if got != want {
+			t.Errorf("\n  got: %s\n  want: %s", got, want)
+			return
+		}
+	}
+}
+
+func TestSnapshot2(t *testing.T) {
+	got := snapshotTestHelper().(*Error).Snapshot()
+
+	// trace.Values や frame は含まれない
+	for _, unwanted := range []string{"Secret", "value", "snapshot_test.go", "snapshotTestHelper"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("\n  got: %s\n  want: not contains %s", got, unwanted)
+			return
+		}
+	}
+}
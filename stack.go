@@ -0,0 +1,93 @@
+package ers
+
+import (
+	"runtime"
+
+	"golang.org/x/xerrors"
+)
+
+// DedupStackFrames は, %+v でスタックを出力する際にラップ先のスタックと重複するフレームを省略するかどうか.
+// ヘルパー経由で多段にラップした場合に, 同じ呼び出し元のフレームが層ごとに繰り返し出力されるのを抑える.
+var DedupStackFrames = false
+
+// maxStackDepth は, エラー生成時に記録するスタックの最大フレーム数.
+const maxStackDepth = 32
+
+// callers は, skip 段上の呼び出し元から最大 maxStackDepth フレームのスタックを返す.
+// callers(0) は callers の呼び出し元を起点とする.
+func callers(skip int) []uintptr {
+	// 記録したフレーム数分だけ確保するよう, 一度固定長の配列に受け取ってからコピーする
+	var pcs [maxStackDepth]uintptr
+	n := runtime.Callers(skip+2, pcs[:])
+	return append([]uintptr(nil), pcs[:n]...)
+}
+
+// caller は, callers と同じ起点で skip 段上の呼び出し元のプログラムカウンタのみを返す.
+func caller(skip int) uintptr {
+	var pcs [1]uintptr
+	runtime.Callers(skip+2, pcs[:])
+	return pcs[0]
+}
+
+// StackTrace は, エラー生成時に記録したスタックのプログラムカウンタを返す.
+func (e *Error) StackTrace() []uintptr {
+	return e.stack
+}
+
+// DedupFrames は, err のラップチェーン全体のスタックを内側の層から順に連結し,
+// 既に現れたフレームを除いたプログラムカウンタを返す.
+// ラップした層のスタックのうち, ラップ先と共通する呼び出し元のフレームは除かれる.
+func DedupFrames(err error) []uintptr {
+	var pcs []uintptr
+	seen := map[uintptr]bool{}
+	v := layers(err)
+	for i := len(v) - 1; i >= 0; i-- {
+		for _, pc := range v[i].stack {
+			if !seen[pc] {
+				seen[pc] = true
+				pcs = append(pcs, pc)
+			}
+		}
+	}
+	return pcs
+}
+
+// dedupStack は, 記録したスタックからラップ先のスタックと重複するフレームを除いて返す.
+func (e *Error) dedupStack() []uintptr {
+	inner := map[uintptr]bool{}
+	for _, v := range layers(e.error) {
+		for _, pc := range v.stack {
+			inner[pc] = true
+		}
+	}
+	var pcs []uintptr
+	for _, pc := range e.stack {
+		if !inner[pc] {
+			pcs = append(pcs, pc)
+		}
+	}
+	return pcs
+}
+
+// formatStack は, 記録したスタックを xerrors.Frame と同じ形式で出力する.
+// DedupStackFrames が true の場合は, ラップ先と重複するフレームを省略する.
+func (e *Error) formatStack(p xerrors.Printer) {
+	if !p.Detail() {
+		return
+	}
+	stack := e.stack
+	if DedupStackFrames {
+		stack = e.dedupStack()
+	}
+	if len(stack) == 0 {
+		return
+	}
+	frames := runtime.CallersFrames(stack)
+	for {
+		frame, more := frames.Next()
+		p.Printf("%s\n    %s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package ers
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func stackTestHelper() error {
+	return W(&testErrorPtr{})
+}
+
+func TestStackTrace1(t *testing.T) {
+	tests := []error{
+		New(0, "reason", "message"),
+		ErrInternal.WithTrace("trace"),
+		stackTestHelper(),
+	}
+	for _, test := range tests {
+		stack := test.(*Error).StackTrace()
+		if len(stack) == 0 || len(stack) > maxStackDepth {
+			t.Errorf("\n  got: %d frames\n  want: 1-%d frames", len(stack), maxStackDepth)
+			return
+		}
+
+		// 先頭のフレームはエラーを生成した関数
+		frame, _ := runtime.CallersFrames(stack).Next()
+		if !strings.HasPrefix(frame.Function, "github.com/tys-muta/go-ers.") || strings.HasSuffix(frame.Function, ".New") {
+			t.Errorf("\n  got: %s\n  want: caller of constructor", frame.Function)
+			return
+		}
+	}
+}
+
+func TestStackTrace2(t *testing.T) {
+	got := fmt.Sprintf("%+v", stackTestHelper())
+
+	// ラップした関数だけでなく, その呼び出し元も出力される
+	for _, want := range []string{"stackTestHelper", "TestStackTrace2", "stack_test.go"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("\n  got: %s\n  want: contains %s", got, want)
+			return
+		}
+	}
+}
+
+func frameTestNew(skip int) *Error {
+	return New(0, "reason", "message", WithFrame(skip))
+}
+
+func frameTestWrap(skip int) error {
+	return W(&testErrorPtr{}, WithFrame(skip))
+}
+
+// frameFunction は, err に記録された先頭フレームとスタックの先頭の関数名を返す.
+func frameFunction(err error) (string, string) {
+	e := err.(*Error)
+	frame := fmt.Sprintf("%+v", &Error{frame: e.frame})
+	top, _ := runtime.CallersFrames(e.StackTrace()).Next()
+	return frame, top.Function
+}
+
+func TestWithFrame1(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: frameTestNew(0), want: "frameTestNew"},
+		{err: frameTestNew(1), want: "TestWithFrame1"},
+		{err: frameTestWrap(0), want: "frameTestWrap"},
+		{err: frameTestWrap(1), want: "TestWithFrame1"},
+	}
+	for _, test := range tests {
+		frame, top := frameFunction(test.err)
+		if !strings.Contains(frame, "."+test.want+"\n") {
+			t.Errorf("\n  got: %s\n  want: contains %s", frame, test.want)
+			return
+		}
+		if !strings.HasSuffix(top, "."+test.want) {
+			t.Errorf("\n  got: %s\n  want: %s", top, test.want)
+			return
+		}
+	}
+}
+
+func dedupTestInner() error {
+	return New(0, "reason", "message")
+}
+
+func dedupTestOuter() error {
+	return W(dedupTestInner())
+}
+
+func TestDedupFrames1(t *testing.T) {
+	err := dedupTestOuter()
+	inner := errors.Unwrap(err).(*Error).StackTrace()
+	outer := err.(*Error).StackTrace()
+
+	// ラップした層のうちラップ先と重複しないのは dedupTestOuter 内で W を呼んだフレームのみ
+	got := DedupFrames(err)
+	if len(got) != len(inner)+1 {
+		t.Errorf("\n  got: %d frames\n  want: %d frames", len(got), len(inner)+1)
+		return
+	}
+	if got[len(got)-1] != outer[0] {
+		t.Errorf("\n  got: %v\n  want: %v", got[len(got)-1], outer[0])
+		return
+	}
+	seen := map[uintptr]bool{}
+	for _, pc := range got {
+		if seen[pc] {
+			t.Errorf("\n  got: duplicated %v\n  want: unique frames", pc)
+			return
+		}
+		seen[pc] = true
+	}
+
+	if got := DedupFrames(fmt.Errorf("plain")); got != nil {
+		t.Errorf("\n  got: %v\n  want: nil", got)
+		return
+	}
+}
+
+func TestDedupFrames2(t *testing.T) {
+	t.Cleanup(func() { DedupStackFrames = false })
+
+	tests := []struct {
+		dedup bool
+		want  int
+	}{
+		{dedup: false, want: 2},
+		// ラップ先と重複するフレームは一度だけ出力する
+		{dedup: true, want: 1},
+	}
+	for _, test := range tests {
+		DedupStackFrames = test.dedup
+		got := fmt.Sprintf("%+v", dedupTestOuter())
+		if n := strings.Count(got, ".TestDedupFrames2"); n != test.want {
+			t.Errorf("\n  got: %d\n  want: %d\n%s", n, test.want, got)
+			return
+		}
+		if !strings.Contains(got, "dedupTestOuter") || !strings.Contains(got, "dedupTestInner") {
+			t.Errorf("\n  got: %s\n  want: contains dedupTestOuter and dedupTestInner", got)
+			return
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package ers
+
+// Tags は, 自身に付与されたタグを返す.
+// ラップ先のタグは含めない.
+func (e *Error) Tags() []string {
+	return append([]string(nil), e.tags...)
+}
+
+// AllTags は, err のラップチェーン全体のタグを外側の層から順に重複を除いて返す.
+func AllTags(err error) []string {
+	var tags []string
+	seen := map[string]bool{}
+	Walk(err, func(err error) bool {
+		if v, ok := err.(*Error); ok {
+			for _, tag := range v.tags {
+				if !seen[tag] {
+					seen[tag] = true
+					tags = append(tags, tag)
+				}
+			}
+		}
+		return true
+	})
+	return tags
+}
+
+// HasTag は, err のラップチェーン中に tag が付与されている場合に true を返す.
+func HasTag(err error, tag string) bool {
+	found := false
+	Walk(err, func(err error) bool {
+		if v, ok := err.(*Error); ok {
+			for _, t := range v.tags {
+				if t == tag {
+					found = true
+					return false
+				}
+			}
+		}
+		return true
+	})
+	return found
+}
@@ -0,0 +1,60 @@
+package ers
+
+import (
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestTags1(t *testing.T) {
+	inner := New(codes.Unavailable, "Database", "", WithTags("db", "timeout"))
+	err := W(fmt.Errorf("wrap: %w", W(inner, WithTags("retry"))), WithTags("db", "api")).(*Error)
+
+	if got := fmt.Sprint(err.Tags()); got != "[db api]" {
+		t.Errorf("\n  got: %s\n  want: %s", got, "[db api]")
+		return
+	}
+	if got := fmt.Sprint(inner.Tags()); got != "[db timeout]" {
+		t.Errorf("\n  got: %s\n  want: %s", got, "[db timeout]")
+		return
+	}
+	if got := fmt.Sprint(AllTags(err)); got != "[db api retry timeout]" {
+		t.Errorf("\n  got: %s\n  want: %s", got, "[db api retry timeout]")
+		return
+	}
+
+	// 返り値を変更しても元のタグは変わらない
+	err.Tags()[0] = "changed"
+	if got := err.Tags()[0]; got != "db" {
+		t.Errorf("\n  got: %s\n  want: %s", got, "db")
+		return
+	}
+}
+
+func TestHasTag1(t *testing.T) {
+	err := W(W(ErrUnavailable, WithTags("db")), WithTags("api"))
+
+	tests := []struct {
+		err  error
+		tag  string
+		want bool
+	}{
+		{err: err, tag: "api", want: true},
+		{err: err, tag: "db", want: true},
+		{err: err, tag: "timeout", want: false},
+		{err: ErrUnavailable, tag: "db", want: false},
+		{err: Join(ErrInternal, err), tag: "db", want: true},
+		{err: nil, tag: "db", want: false},
+	}
+	for _, test := range tests {
+		if got := HasTag(test.err, test.tag); got != test.want {
+			t.Errorf("[%s]\n  got: %t\n  want: %t", test.tag, got, test.want)
+			return
+		}
+	}
+	if got := AllTags(ErrUnavailable); got != nil {
+		t.Errorf("\n  got: %v\n  want: <nil>", got)
+		return
+	}
+}
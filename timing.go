@@ -0,0 +1,33 @@
+package ers
+
+import "time"
+
+// Age は, エラーを生成してからの経過時間を返す.
+// ラップした層では, その層を生成してからの経過時間を返す.
+// 生成時刻が記録されていない場合は 0 を返す.
+func (e *Error) Age() time.Duration {
+	if e.createdAt.IsZero() {
+		return 0
+	}
+	return nowFunc().Sub(e.createdAt)
+}
+
+// OldestAge は, err のラップチェーン全体で最も古い生成時刻からの経過時間を返す.
+// 通常はラップ元のエラーを生成してからの経過時間になる.
+// NewR や Catalog で定義した番兵エラーの生成時刻は数えず, ラップした層から数える.
+// *Error を含まない場合は 0 を返す.
+func OldestAge(err error) time.Duration {
+	var oldest time.Time
+	Walk(err, func(err error) bool {
+		if v, ok := err.(*Error); ok && !v.sentinel && !v.createdAt.IsZero() {
+			if oldest.IsZero() || v.createdAt.Before(oldest) {
+				oldest = v.createdAt
+			}
+		}
+		return true
+	})
+	if oldest.IsZero() {
+		return 0
+	}
+	return nowFunc().Sub(oldest)
+}
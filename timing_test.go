@@ -0,0 +1,72 @@
+package ers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestAge1(t *testing.T) {
+	now := testNow
+	nowFunc = func() time.Time { return now }
+	t.Cleanup(func() { nowFunc = time.Now })
+
+	src := New(codes.NotFound, "UserNotFound", "")
+	now = now.Add(2 * time.Second)
+	err := W(fmt.Errorf("repository: %w", src)).(*Error)
+	now = now.Add(3 * time.Second)
+
+	tests := []struct {
+		got  time.Duration
+		want time.Duration
+	}{
+		{got: src.Age(), want: 5 * time.Second},
+		{got: err.Age(), want: 3 * time.Second},
+		// ラップ元の生成時刻まで辿る
+		{got: OldestAge(err), want: 5 * time.Second},
+		{got: OldestAge(src), want: 5 * time.Second},
+		{got: OldestAge(errors.New("plain")), want: 0},
+		{got: OldestAge(nil), want: 0},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("\n  got: %s\n  want: %s", test.got, test.want)
+			return
+		}
+	}
+}
+
+func TestAge2(t *testing.T) {
+	now := testNow
+	nowFunc = func() time.Time { return now }
+	t.Cleanup(func() { nowFunc = time.Now })
+
+	catalog := NewCatalog("users")
+	sentinel := catalog.Define(codes.NotFound, "UserNotFound", "")
+	fresh := NewR(codes.NotFound, ReasonNotFound, "")
+	now = now.Add(time.Hour)
+	err1 := W(ErrNotFound)
+	err2 := W(fmt.Errorf("repository: %w", sentinel))
+	now = now.Add(2 * time.Second)
+
+	tests := []struct {
+		got  time.Duration
+		want time.Duration
+	}{
+		// 番兵エラーの定義時刻は数えない
+		{got: OldestAge(err1), want: 2 * time.Second},
+		{got: OldestAge(err2), want: 2 * time.Second},
+		{got: OldestAge(ErrNotFound), want: 0},
+		// 実行時に NewR で生成した場合も生成時刻は記録する
+		{got: fresh.Age(), want: time.Hour + 2*time.Second},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("\n  got: %s\n  want: %s", test.got, test.want)
+			return
+		}
+	}
+}
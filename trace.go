@@ -1,33 +1,275 @@
 package ers
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
+// nowFunc は, 現在時刻を返す. テストで差し替えられるよう変数として持つ.
+var nowFunc = time.Now
+
 var (
 	// T 関数は, NewTrace 関数のエイリアス.
 	T = NewTrace
+	// Tf 関数は, NewTracef 関数のエイリアス.
+	Tf = NewTracef
 )
 
 type Trace struct {
 	Text   string
 	Values []any
+	Fields map[string]any
+	// Trace を生成した時刻
+	Time time.Time
+	// Dump で値を展開するネストの深さ. 0 の場合は制限しない.
+	MaxDepth int
+	// Dump の出力の最大バイト数. 0 の場合は制限しない.
+	MaxBytes int
+	// Dump で値を伏せるフィールド名
+	sanitizeKeys []string
+	// Dump の呼び出し時に評価する Values
+	lazy *lazyValues
+}
+
+// lazyValues は, 一度だけ評価して結果を保持する Values.
+// Trace をコピーしても評価結果を共有するようポインタで保持する.
+type lazyValues struct {
+	once   sync.Once
+	fn     func() []any
+	values []any
+}
+
+func (l *lazyValues) get() []any {
+	l.once.Do(func() {
+		l.values = l.fn()
+	})
+	return l.values
 }
 
 func NewTrace(src any) *Trace {
 	switch v := src.(type) {
 	case string:
-		return &Trace{Text: v}
+		return newTrace(v)
 	case []byte:
-		return &Trace{Text: string(v)}
+		return newTrace(string(v))
 	case error:
-		return &Trace{Text: v.Error()}
+		return newTrace(v.Error())
 	case *Trace:
 		if v != nil {
-			return &Trace{Text: v.Text, Values: v.Values}
+			c := *v
+			return &c
 		}
 	case Trace:
 		return &v
 	}
-	return &Trace{Text: fmt.Sprintf("%s", src)}
+	return newTrace(fmt.Sprintf("%s", src))
+}
+
+// newTrace は, text を Text とし, 現在時刻を Time とする Trace を返す.
+func newTrace(text string) *Trace {
+	return &Trace{Text: text, Time: nowFunc()}
+}
+
+// NewTracef は, format に従って args を書式化した文字列を Text とする Trace を返す.
+// args は構造体であっても Values には格納せず, Text に埋め込む.
+func NewTracef(format string, args ...any) *Trace {
+	return newTrace(fmt.Sprintf(format, args...))
+}
+
+// NewLazyTrace は, Dump の呼び出し時に fn を評価して Values に加える Trace を返す.
+// fn は Dump が呼ばれるまで評価せず, 複数回 Dump しても一度だけ評価する.
+func NewLazyTrace(fn func() []any) *Trace {
+	t := newTrace("")
+	t.lazy = &lazyValues{fn: fn}
+	return t
+}
+
+// With は, key と value をフィールドに追加した新しい Trace を返す.
+// レシーバの Trace は変更しない.
+func (t *Trace) With(key string, value any) *Trace {
+	v := &Trace{
+		Text:     t.Text,
+		Values:   t.Values,
+		Fields:   make(map[string]any, len(t.Fields)+1),
+		Time:     t.Time,
+		MaxDepth: t.MaxDepth,
+		MaxBytes: t.MaxBytes,
+
+		sanitizeKeys: t.sanitizeKeys,
+		lazy:         t.lazy,
+	}
+	for k, f := range t.Fields {
+		v.Fields[k] = f
+	}
+	v.Fields[key] = value
+	return v
+}
+
+// Dump は, Text, Values, Fields を 1 行ずつ出力する.
+// Time が設定されている場合は, 先頭行の Text の前に RFC3339 形式の時刻を付与する.
+// NewTrace 系で生成した Trace は時刻が設定されるため, 先頭行の形式は時刻の付与前と異なる.
+// Fields はキー順にソートして出力する.
+// MaxDepth が指定されている場合は, その深さまでネストを展開する.
+// MaxBytes が指定されている場合は, 超えた分を rune の境界で切り詰めて "...(truncated)" を付与する.
+func (t *Trace) Dump() string {
+	first := t.Text
+	if !t.Time.IsZero() {
+		first = t.Time.Format(time.RFC3339)
+		if t.Text != "" {
+			first += " " + t.Text
+		}
+	}
+	lines := []string{first}
+	for _, v := range t.values() {
+		lines = append(lines, dumpValue(v, t.MaxDepth, t.sanitizeKeys...))
+	}
+
+	keys := make([]string, 0, len(t.Fields))
+	for k := range t.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if matchKey(k, t.sanitizeKeys) {
+			lines = append(lines, k+"="+sanitizedValue)
+			continue
+		}
+		lines = append(lines, k+"="+dumpValue(t.Fields[k], t.MaxDepth, t.sanitizeKeys...))
+	}
+	return truncate(strings.Join(lines, "\n"), t.MaxBytes)
+}
+
+// DumpJSON は, Text, Values, Fields を {"text":"...","values":[...],"fields":{...}} 形式の JSON にする.
+// Dump のテキスト出力とは別に, ログ基盤で扱えるよう決定的な JSON を出力する.
+// JSON にできない値 (関数やチャネルなど) は型名の文字列にする.
+// Sanitize で指定した名前のフィールドやキーの値は "***" に置き換える.
+func (t *Trace) DumpJSON() ([]byte, error) {
+	v := struct {
+		Text   string                     `json:"text"`
+		Values []json.RawMessage          `json:"values"`
+		Fields map[string]json.RawMessage `json:"fields,omitempty"`
+	}{
+		Text:   t.Text,
+		Values: []json.RawMessage{},
+	}
+	for _, value := range t.values() {
+		v.Values = append(v.Values, jsonValue(value, t.sanitizeKeys...))
+	}
+	for k, value := range t.Fields {
+		if v.Fields == nil {
+			v.Fields = map[string]json.RawMessage{}
+		}
+		if matchKey(k, t.sanitizeKeys) {
+			v.Fields[k] = jsonValue(sanitizedValue)
+			continue
+		}
+		v.Fields[k] = jsonValue(value, t.sanitizeKeys...)
+	}
+	return json.Marshal(v)
+}
+
+// values は, 遅延評価する値を含めた Values を返す.
+func (t *Trace) values() []any {
+	if t.lazy == nil {
+		return t.Values
+	}
+	return append(append([]any(nil), t.Values...), t.lazy.get()...)
+}
+
+// TraceValueOf は, t の Values の中で型 T に一致する最初の値を返す.
+// NewLazyTrace で遅延評価する値も対象とする.
+// 一致する値が無い場合は T のゼロ値と false を返す.
+func TraceValueOf[T any](t *Trace) (T, bool) {
+	if t != nil {
+		for _, v := range t.values() {
+			if value, ok := v.(T); ok {
+				return value, true
+			}
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// Sanitize は, keys に一致する名前のフィールドの値を Dump で "***" に置き換える Trace のコピーを返す.
+// 名前は大文字小文字を区別せずに比較し, ネストした構造体やマップ, Fields のキーにも再帰的に適用する.
+// レシーバの Trace は変更しない.
+func (t *Trace) Sanitize(keys ...string) *Trace {
+	v := NewTrace(t)
+	v.Values = append([]any(nil), t.Values...)
+	v.sanitizeKeys = append(append([]string(nil), t.sanitizeKeys...), keys...)
+	return v
+}
+
+// Traces は, ラップチェーン全体の Trace をルート (最も内側) から順に返す.
+// trace が未設定の層はスキップする.
+func (e *Error) Traces() []Trace {
+	traces := []Trace{}
+	var err error = e
+	for i := 0; err != nil && i < maxUnwrapDepth; i++ {
+		if v, ok := err.(*Error); ok && !v.trace.isEmpty() {
+			traces = append(traces, *v.trace)
+		}
+		err = errors.Unwrap(err)
+	}
+
+	for i, j := 0, len(traces)-1; i < j; i, j = i+1, j-1 {
+		traces[i], traces[j] = traces[j], traces[i]
+	}
+	return traces
+}
+
+// TreeDump は, err のラップチェーンの *Error の層ごとに "[code] reason: trace.Text" を 1 行ずつ,
+// 最も外側の層を先頭に 2 スペースずつインデントして出力する.
+// 各層の code と reason はその層から解決した値とする.
+func TreeDump(err error) string {
+	return treeDump(layers(err))
+}
+
+// TreeDumpReverse は, TreeDump と逆にルート (最も内側) の層を先頭にして出力する.
+func TreeDumpReverse(err error) string {
+	v := layers(err)
+	for i, j := 0, len(v)-1; i < j; i, j = i+1, j-1 {
+		v[i], v[j] = v[j], v[i]
+	}
+	return treeDump(v)
+}
+
+// layers は, err のラップチェーンの *Error の層を外側から順に返す.
+func layers(err error) []*Error {
+	var v []*Error
+	for i := 0; err != nil && i < maxUnwrapDepth; i++ {
+		if e, ok := err.(*Error); ok {
+			v = append(v, e)
+		}
+		err = errors.Unwrap(err)
+	}
+	return v
+}
+
+func treeDump(layers []*Error) string {
+	lines := make([]string, 0, len(layers))
+	for i, e := range layers {
+		line := strings.Repeat("  ", i) + "[" + e.Code().String() + "]"
+		if reason := e.Reason(); reason != "" {
+			line += " " + reason
+		}
+		if e.trace != nil && e.trace.Text != "" {
+			if reason := e.Reason(); reason != "" {
+				line += ":"
+			}
+			line += " " + e.trace.Text
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (t *Trace) isEmpty() bool {
+	return t == nil || (t.Text == "" && len(t.Values) == 0 && len(t.Fields) == 0 && t.lazy == nil)
 }
@@ -1,9 +1,25 @@
 package ers
 
 import (
+	"fmt"
+	"math"
+	"strings"
 	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
 )
 
+// testNow は, fixNow で固定する現在時刻.
+var testNow = time.Date(2022, 6, 27, 12, 0, 0, 0, time.UTC)
+
+// fixNow は, テストの間 nowFunc が testNow を返すよう差し替える.
+func fixNow(t *testing.T) {
+	t.Helper()
+	nowFunc = func() time.Time { return testNow }
+	t.Cleanup(func() { nowFunc = time.Now })
+}
+
 func TestNewTrace1(t *testing.T) {
 	text := "text"
 	trace := NewTrace(text)
@@ -38,3 +54,382 @@ func TestNewTrace2(t *testing.T) {
 		})
 	}
 }
+
+func TestNewTracef1(t *testing.T) {
+	type user struct {
+		ID   int
+		Name string
+	}
+	tests := []struct {
+		trace *Trace
+		want  string
+	}{
+		{trace: NewTracef("user %d not found", 1), want: "user 1 not found"},
+		{trace: Tf("%s: %q", "key", "value"), want: `key: "value"`},
+		{trace: Tf("%+v", user{ID: 1, Name: "name"}), want: "{ID:1 Name:name}"},
+		{trace: Tf("no args"), want: "no args"},
+	}
+	for _, test := range tests {
+		if test.trace.Text != test.want {
+			t.Errorf("\n  got: %s\n  want: %s", test.trace.Text, test.want)
+			return
+		}
+		if len(test.trace.Values) != 0 {
+			t.Errorf("\n  got: %v\n  want: []", test.trace.Values)
+			return
+		}
+	}
+}
+
+func TestTraceWith1(t *testing.T) {
+	src := NewTrace("text")
+	got := src.With("user_id", 1).With("request_id", "abc")
+
+	// With は新しいコピーを返し, 元の Trace は変更しない
+	if len(src.Fields) != 0 {
+		t.Errorf("\n  got: %v\n  want: empty", src.Fields)
+		return
+	}
+	if got.Text != src.Text {
+		t.Errorf("\n  got: %s\n  want: %s", got.Text, src.Text)
+		return
+	}
+	if got.Fields["user_id"] != 1 || got.Fields["request_id"] != "abc" {
+		t.Errorf("\n  got: %v\n  want: user_id=1 request_id=abc", got.Fields)
+		return
+	}
+
+	// 分岐させても互いに影響しない
+	a := got.With("k", "a")
+	b := got.With("k", "b")
+	if a.Fields["k"] != "a" || b.Fields["k"] != "b" {
+		t.Errorf("\n  got: %v, %v\n  want: a, b", a.Fields["k"], b.Fields["k"])
+		return
+	}
+}
+
+func TestTraceDump1(t *testing.T) {
+	fixNow(t)
+
+	trace := NewTrace("text").With("user_id", 1).With("request_id", "abc")
+	trace.Values = []any{struct{ ID int }{ID: 2}}
+
+	want := "2022-06-27T12:00:00Z text\n{ID:2}\nrequest_id=abc\nuser_id=1"
+	if got := trace.Dump(); got != want {
+		t.Errorf("\n  got: %s\n  want: %s", got, want)
+		return
+	}
+}
+
+func TestTraces1(t *testing.T) {
+	err := W(W(W(ErrInternal.WithTrace("1"), WithTrace("2"))), WithTrace("3")).(*Error)
+
+	want := []string{"1", "2", "3"}
+	got := err.Traces()
+	if len(got) != len(want) {
+		t.Errorf("\n  got: %v\n  want: %v", got, want)
+		return
+	}
+	for i := range want {
+		if got[i].Text != want[i] {
+			t.Errorf("\n  got: %s\n  want: %s", got[i].Text, want[i])
+			return
+		}
+	}
+
+	// %+v では外側の層から順に各層の trace が表示される
+	text := fmt.Sprintf("%+v", err)
+	if i3, i2, i1 := strings.Index(text, "3:\n"), strings.Index(text, "- 2:\n"), strings.Index(text, ": 1:\n"); !(0 <= i3 && i3 < i2 && i2 < i1) {
+		t.Errorf("\n  got: %s\n  want: traces in order", text)
+		return
+	}
+
+	if got := ErrInternal.Traces(); len(got) != 0 {
+		t.Errorf("\n  got: %v\n  want: empty", got)
+		return
+	}
+}
+
+func TestTraceSanitize1(t *testing.T) {
+	fixNow(t)
+
+	type Credential struct {
+		User     string
+		Password string
+		Token    []byte
+	}
+	type Request struct {
+		ID         int
+		Credential *Credential
+		Headers    map[string]string
+	}
+
+	src := NewTrace("text").With("token", "secret")
+	src.Values = []any{Request{
+		ID:         1,
+		Credential: &Credential{User: "user", Password: "secret", Token: []byte("secret")},
+		Headers:    map[string]string{"Authorization": "secret", "Accept": "*/*"},
+	}}
+	got := src.Sanitize("password", "token", "authorization").Dump()
+
+	want := "2022-06-27T12:00:00Z text\n{ID:1 Credential:&{User:user Password:*** Token:***} Headers:map[Accept:*/* Authorization:***]}\ntoken=***"
+	if got != want {
+		t.Errorf("\n  got: %s\n  want: %s", got, want)
+		return
+	}
+
+	// 元の Trace は変更しない
+	if strings.Contains(src.Dump(), "***") {
+		t.Errorf("\n  got: %s\n  want: not sanitized", src.Dump())
+		return
+	}
+}
+
+func TestTraceSanitize2(t *testing.T) {
+	fixNow(t)
+
+	type Node struct {
+		Secret string
+		Next   *Node
+	}
+	node := &Node{Secret: "secret"}
+	node.Next = node
+
+	trace := NewTrace("text")
+	trace.Values = []any{node}
+
+	// 循環参照しても無限ループしない
+	want := "2022-06-27T12:00:00Z text\n&{Secret:*** Next:<circular reference>}"
+	if got := trace.Sanitize("Secret").Dump(); got != want {
+		t.Errorf("\n  got: %s\n  want: %s", got, want)
+		return
+	}
+}
+
+func TestTraceTime1(t *testing.T) {
+	fixNow(t)
+
+	tests := []struct {
+		trace *Trace
+		want  string
+	}{
+		{trace: NewTrace("text"), want: "2022-06-27T12:00:00Z text"},
+		{trace: Tf("user %d", 1), want: "2022-06-27T12:00:00Z user 1"},
+		{trace: NewTrace(""), want: "2022-06-27T12:00:00Z"},
+		{trace: NewTrace("text").With("k", "v"), want: "2022-06-27T12:00:00Z text\nk=v"},
+		// 時刻が設定されていない場合は従来どおり Text のみを出力する
+		{trace: &Trace{Text: "text"}, want: "text"},
+	}
+	for _, test := range tests {
+		if !test.trace.Time.IsZero() && !test.trace.Time.Equal(testNow) {
+			t.Errorf("\n  got: %s\n  want: %s", test.trace.Time, testNow)
+			return
+		}
+		if got := test.trace.Dump(); got != test.want {
+			t.Errorf("\n  got: %s\n  want: %s", got, test.want)
+			return
+		}
+	}
+
+	// コピーしても生成時の時刻を引き継ぐ
+	src := NewTrace("text")
+	nowFunc = func() time.Time { return testNow.Add(time.Hour) }
+	if got := NewTrace(src).Time; !got.Equal(testNow) {
+		t.Errorf("\n  got: %s\n  want: %s", got, testNow)
+		return
+	}
+}
+
+func TestNewLazyTrace1(t *testing.T) {
+	count := 0
+	trace := NewLazyTrace(func() []any {
+		count++
+		return []any{struct{ ID int }{ID: 1}}
+	})
+
+	// Dump を呼ぶまで評価しない
+	err := W(ErrInternal, WithTrace(trace)).(*Error)
+	_ = err.Error()
+	_ = err.GRPCStatus()
+	if count != 0 {
+		t.Errorf("\n  got: %d\n  want: %d", count, 0)
+		return
+	}
+
+	// 複数回 Dump しても一度だけ評価する
+	for i := 0; i < 3; i++ {
+		if got, want := trace.Dump(), "{ID:1}"; !strings.HasSuffix(got, "\n"+want) {
+			t.Errorf("\n  got: %s\n  want: suffix %s", got, want)
+			return
+		}
+	}
+	if got := err.trace.Dump(); !strings.HasSuffix(got, "\n{ID:1}") {
+		t.Errorf("\n  got: %s\n  want: suffix %s", got, "{ID:1}")
+		return
+	}
+	if count != 1 {
+		t.Errorf("\n  got: %d\n  want: %d", count, 1)
+		return
+	}
+}
+
+func TestTraceDumpJSON1(t *testing.T) {
+	type User struct {
+		ID       int
+		Password string
+	}
+	trace := NewTrace("text").With("token", "abc").With("user_id", 1)
+	trace.Values = []any{User{ID: 1, Password: "secret"}, "value", func() {}, make(chan int)}
+
+	tests := []struct {
+		trace *Trace
+		want  string
+	}{
+		{
+			trace: trace,
+			want:  `{"text":"text","values":[{"ID":1,"Password":"secret"},"value","func()","chan int"],"fields":{"token":"abc","user_id":1}}`,
+		},
+		{
+			trace: trace.Sanitize("password", "token"),
+			want:  `{"text":"text","values":[{"ID":1,"Password":"***"},"value","func()","chan int"],"fields":{"token":"***","user_id":1}}`,
+		},
+		{
+			trace: &Trace{},
+			want:  `{"text":"","values":[]}`,
+		},
+		{
+			// 2^53 を超える整数も精度を落とさない
+			trace: (&Trace{Values: []any{User{ID: math.MaxInt64}}}).With("id", int64(9007199254740993)).Sanitize("password"),
+			want:  `{"text":"","values":[{"ID":9223372036854775807,"Password":"***"}],"fields":{"id":9007199254740993}}`,
+		},
+	}
+	for _, test := range tests {
+		// 複数回出力しても同じ結果になる
+		for i := 0; i < 3; i++ {
+			got, err := test.trace.DumpJSON()
+			if err != nil {
+				t.Errorf("\n  got: %v\n  want: <nil>", err)
+				return
+			}
+			if string(got) != test.want {
+				t.Errorf("\n  got: %s\n  want: %s", got, test.want)
+				return
+			}
+		}
+	}
+}
+
+func TestTraceMaxBytes1(t *testing.T) {
+	trace := &Trace{Text: "text", Values: []any{make([]int, 100)}}
+
+	tests := []struct {
+		maxBytes int
+		want     string
+	}{
+		{maxBytes: 0, want: "text\n[" + strings.TrimSuffix(strings.Repeat("0 ", 100), " ") + "]"},
+		{maxBytes: 10, want: "text\n[0 0 " + truncatedSuffix},
+		{maxBytes: 1000, want: "text\n[" + strings.TrimSuffix(strings.Repeat("0 ", 100), " ") + "]"},
+	}
+	for _, test := range tests {
+		trace.MaxBytes = test.maxBytes
+		if got := trace.Dump(); got != test.want {
+			t.Errorf("\n  got: %s\n  want: %s", got, test.want)
+			return
+		}
+	}
+
+	// マルチバイト文字の途中では切らない
+	trace = &Trace{Text: "あいうえお", MaxBytes: 7}
+	if got, want := trace.Dump(), "あい"+truncatedSuffix; got != want {
+		t.Errorf("\n  got: %s\n  want: %s", got, want)
+		return
+	}
+
+	// With でコピーしても上限は引き継がれる
+	if got := trace.With("k", "v").MaxBytes; got != 7 {
+		t.Errorf("\n  got: %d\n  want: %d", got, 7)
+		return
+	}
+}
+
+func TestTraceValueOf1(t *testing.T) {
+	type user struct{ ID int }
+
+	trace := NewTrace("text")
+	trace.Values = []any{"request", 1, user{ID: 2}, &user{ID: 3}, user{ID: 4}}
+
+	if got, ok := TraceValueOf[user](trace); !ok || got.ID != 2 {
+		t.Errorf("\n  got: %v, %t\n  want: %v, %t", got, ok, user{ID: 2}, true)
+		return
+	}
+	if got, ok := TraceValueOf[*user](trace); !ok || got.ID != 3 {
+		t.Errorf("\n  got: %v, %t\n  want: %v, %t", got, ok, &user{ID: 3}, true)
+		return
+	}
+	if got, ok := TraceValueOf[int](trace); !ok || got != 1 {
+		t.Errorf("\n  got: %v, %t\n  want: %v, %t", got, ok, 1, true)
+		return
+	}
+	if got, ok := TraceValueOf[error](trace); ok || got != nil {
+		t.Errorf("\n  got: %v, %t\n  want: %v, %t", got, ok, nil, false)
+		return
+	}
+	if got, ok := TraceValueOf[float64](trace); ok || got != 0 {
+		t.Errorf("\n  got: %v, %t\n  want: %v, %t", got, ok, 0, false)
+		return
+	}
+	if _, ok := TraceValueOf[string](nil); ok {
+		t.Errorf("\n  got: %t\n  want: %t", ok, false)
+		return
+	}
+
+	// 遅延評価する値も対象とする
+	lazy := NewLazyTrace(func() []any { return []any{user{ID: 5}} })
+	if got, ok := TraceValueOf[user](lazy); !ok || got.ID != 5 {
+		t.Errorf("\n  got: %v, %t\n  want: %v, %t", got, ok, user{ID: 5}, true)
+		return
+	}
+}
+
+func TestTreeDump1(t *testing.T) {
+	src := New(codes.NotFound, "UserNotFound", "", WithTrace("user_id: 1"))
+	err := W(fmt.Errorf("repository: %w", W(src, WithTrace("find user"))), WithCode(codes.Internal), WithTrace("handler"))
+
+	want := strings.Join([]string{
+		"[Internal] UserNotFound: handler",
+		"  [NotFound] UserNotFound: find user",
+		"    [NotFound] UserNotFound: user_id: 1",
+	}, "\n")
+	if got := TreeDump(err); got != want {
+		t.Errorf("\n  got: %s\n  want: %s", got, want)
+		return
+	}
+
+	want = strings.Join([]string{
+		"[NotFound] UserNotFound: user_id: 1",
+		"  [NotFound] UserNotFound: find user",
+		"    [Internal] UserNotFound: handler",
+	}, "\n")
+	if got := TreeDumpReverse(err); got != want {
+		t.Errorf("\n  got: %s\n  want: %s", got, want)
+		return
+	}
+}
+
+func TestTreeDump2(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: W(New(codes.Unavailable, "", "")), want: "[Unavailable]\n  [Unavailable]"},
+		{err: W(W(ErrNotFound), WithTrace("handler")), want: "[NotFound] NotFound: handler\n  [NotFound] NotFound\n    [NotFound] NotFound"},
+		{err: fmt.Errorf("plain"), want: ""},
+		{err: nil, want: ""},
+	}
+	for _, test := range tests {
+		if got := TreeDump(test.err); got != test.want {
+			t.Errorf("\n  got: %s\n  want: %s", got, test.want)
+			return
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package ers
+
+import (
+	"strings"
+)
+
+// tsvEscaper は, TSV の 1 フィールドに含められない文字をエスケープする.
+var tsvEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"\t", `\t`,
+	"\n", `\n`,
+	"\r", `\r`,
+)
+
+// TSV は, ラップ先まで辿って解決した code/reason/domain/message をタブ区切りの 1 行にして返す.
+// 各フィールドに含まれるバックスラッシュ, タブ, 改行は \\, \t, \n, \r にエスケープする.
+// 末尾に改行は付与しない.
+func (e *Error) TSV() string {
+	fields := []string{e.Code().String(), e.Reason(), e.Domain(), e.Message()}
+	for i, field := range fields {
+		fields[i] = tsvEscaper.Replace(field)
+	}
+	return strings.Join(fields, "\t")
+}
@@ -0,0 +1,37 @@
+package ers
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestTSV1(t *testing.T) {
+	tests := []struct {
+		err  *Error
+		want string
+	}{
+		{err: ErrNotFound.WithDomain("example.com"), want: "NotFound\tNotFound\texample.com\t" + ErrNotFound.message},
+		{err: W(W(ErrNotFound.WithDomain("example.com"))).(*Error), want: "NotFound\tNotFound\texample.com\t" + ErrNotFound.message},
+		{err: New(codes.Internal, "", ""), want: "Internal\t\t\t"},
+		{err: New(codes.Internal, "R", "a\tb"), want: `Internal	R		a\tb`},
+		{err: New(codes.Internal, "R", "line1\nline2\r\n"), want: `Internal	R		line1\nline2\r\n`},
+		{err: New(codes.Internal, "R", `C:\path\to`), want: `Internal	R		C:\\path\\to`},
+		// エスケープ済みに見える文字列も区別できる
+		{err: New(codes.Internal, "R", `\t`), want: `Internal	R		\\t`},
+		{err: New(codes.Internal, "a\tb", "").WithDomain("x\ny"), want: `Internal	a\tb	x\ny	`},
+	}
+	for _, test := range tests {
+		got := test.err.TSV()
+		if got != test.want {
+			t.Errorf("\n  got: %q\n  want: %q", got, test.want)
+			return
+		}
+		// 常に 4 フィールドの 1 行になる
+		if strings.Count(got, "\t") != 3 || strings.ContainsAny(got, "\r\n") {
+			t.Errorf("\n  got: %q\n  want: 4 fields in 1 line", got)
+			return
+		}
+	}
+}
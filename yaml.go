@@ -0,0 +1,47 @@
+package ers
+
+import "fmt"
+
+// yamlError は, YAML に出力するエラーの形式.
+// trace や frame は機密情報を含む可能性があるため出力しない.
+type yamlError struct {
+	Code    string `yaml:"code"`
+	Reason  string `yaml:"reason"`
+	Message string `yaml:"message"`
+	Domain  string `yaml:"domain"`
+}
+
+// MarshalYAML は, ラップ先まで辿って解決した code/reason/message/domain を YAML にする.
+// gopkg.in/yaml.v3 の yaml.Marshaler を実装するが, ers パッケージは YAML ライブラリに依存しない.
+// trace を含めたい場合は ersyaml パッケージを使う.
+func (e *Error) MarshalYAML() (any, error) {
+	return yamlError{
+		Code:    e.Code().String(),
+		Reason:  e.Reason(),
+		Message: e.Message(),
+		Domain:  e.Domain(),
+	}, nil
+}
+
+// UnmarshalYAML は, MarshalYAML で出力した YAML からエラーを復元する.
+// ers パッケージが YAML ライブラリに依存しないよう, gopkg.in/yaml.v3 も対応する
+// unmarshal 関数を受け取る形式で実装する.
+func (e *Error) UnmarshalYAML(unmarshal func(any) error) error {
+	v := yamlError{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+
+	code, ok := CodeFromString(v.Code)
+	if !ok {
+		return fmt.Errorf("ers: unknown code %q", v.Code)
+	}
+
+	*e = Error{
+		code:    code,
+		reason:  v.Reason,
+		message: v.Message,
+		domain:  v.Domain,
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package ers
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestMarshalYAML1(t *testing.T) {
+	src := W(New(codes.NotFound, "UserNotFound", "ユーザーが存在しません。").WithDomain("example.com"), WithTrace("secret")).(*Error)
+
+	v, err := src.MarshalYAML()
+	if err != nil {
+		t.Errorf("Failed to marshal: %s", err)
+		return
+	}
+	want := yamlError{Code: "NotFound", Reason: "UserNotFound", Message: "ユーザーが存在しません。", Domain: "example.com"}
+	if v != want {
+		t.Errorf("\n  got: %v\n  want: %v", v, want)
+		return
+	}
+
+	// YAML ライブラリが MarshalYAML の戻り値を UnmarshalYAML に渡す往復を再現する
+	got := &Error{}
+	if err := got.UnmarshalYAML(func(dst any) error {
+		*dst.(*yamlError) = v.(yamlError)
+		return nil
+	}); err != nil {
+		t.Errorf("Failed to unmarshal: %s", err)
+		return
+	}
+	if got.Code() != src.Code() || got.Reason() != src.Reason() || got.Message() != src.Message() || got.Domain() != src.Domain() {
+		t.Errorf("\n  got: %s\n  want: %s", got.Snapshot(), src.Snapshot())
+		return
+	}
+}
+
+func TestUnmarshalYAML1(t *testing.T) {
+	// 未知のコードはエラーにする
+	err := (&Error{}).UnmarshalYAML(func(dst any) error {
+		*dst.(*yamlError) = yamlError{Code: "Unexpected"}
+		return nil
+	})
+	if err == nil {
+		t.Errorf("\n  got: nil\n  want: error")
+		return
+	}
+}